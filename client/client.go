@@ -4,33 +4,226 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
+	"strconv"
 	"strings"
 	"time"
 )
 
+// TestIDHeader routes request to responses of test, set it in tested service requests
+const TestIDHeader = "X-Supermock-Test-ID"
+
+// Headers can contain several values of the same header.
+// Single string values are decoded too for backward compatibility.
+type Headers map[string][]string
+
+func (h *Headers) UnmarshalJSON(data []byte) error {
+	raw := map[string]json.RawMessage{}
+	err := json.Unmarshal(data, &raw)
+	if err != nil {
+		return err
+	}
+
+	headers := make(Headers, len(raw))
+	for k, v := range raw {
+		values := []string{}
+		err = json.Unmarshal(v, &values)
+		if err == nil {
+			headers[k] = values
+			continue
+		}
+
+		value := ""
+		err = json.Unmarshal(v, &value)
+		if err != nil {
+			return err
+		}
+		headers[k] = []string{value}
+	}
+
+	*h = headers
+
+	return nil
+}
+
 type Request struct {
-	Method  string            `json:"method"`
-	Path    string            `json:"path"`
-	Query   string            `json:"query"`
-	Headers map[string]string `json:"headers"`
-	Body    string            `json:"body"`
+	// Sequence grows monotonically with every captured request
+	Sequence int64   `json:"sequence"`
+	Method   string  `json:"method"`
+	Path     string  `json:"path"`
+	Query    string  `json:"query"`
+	Headers  Headers `json:"headers"`
+	Body     string  `json:"body"`
+	// BodyBase64 is set instead of Body for binary body
+	BodyBase64 string `json:"body_base64,omitempty"`
+	CreatedAt  string `json:"created_at"`
+	// Response that answered the request and what was actually sent
+	ResponseUUID       string  `json:"response_uuid,omitempty"`
+	ResponseStatus     uint    `json:"response_status,omitempty"`
+	ResponseHeaders    Headers `json:"response_headers,omitempty"`
+	ResponseBody       string  `json:"response_body,omitempty"`
+	ResponseBodyBase64 string  `json:"response_body_base64,omitempty"`
+	ResponseFault      string  `json:"response_fault,omitempty"`
+	LatencyMs          int64   `json:"latency_ms"`
+	// Unmatched is set for request without matching response
+	Unmatched bool `json:"unmatched,omitempty"`
 }
 
 type Response struct {
-	UUID         string            `json:"uuid"`
-	TestID       string            `json:"test_id"`
-	Method       string            `json:"method"`
-	Path         string            `json:"path"`
-	Status       uint              `json:"status"`
-	Headers      map[string]string `json:"headers"`
-	Body         string            `json:"body"`
-	IsPermanent  bool              `json:"is_permanent"`
-	DisableCatch bool              `json:"disable_catch"`
+	UUID         string                  `json:"uuid"`
+	TestID       string                  `json:"test_id"`
+	Method       string                  `json:"method"`
+	Path         string                  `json:"path"`
+	PathRegex    string                  `json:"path_regex,omitempty"`
+	Query        *QueryMatcher           `json:"query,omitempty"`
+	MatchHeaders map[string]ValueMatcher `json:"match_headers,omitempty"`
+	MatchBody    *BodyMatcher            `json:"match_body,omitempty"`
+	Status       uint                    `json:"status"`
+	Headers      Headers                 `json:"headers"`
+	Body         string                  `json:"body"`
+	BodyBase64   string                  `json:"body_base64,omitempty"`
+	// BodyJSON is encoded to body, Content-Type is application/json by default
+	BodyJSON     any  `json:"body_json,omitempty"`
+	IsPermanent  bool `json:"is_permanent"`
+	DisableCatch bool `json:"disable_catch"`
+	// Times is number of uses of not permanent response, 0 means once
+	Times uint `json:"times,omitempty"`
+	// Sequence is served in turn instead of status, headers and body
+	Sequence []ResponseVariant `json:"sequence,omitempty"`
+	// SequenceEnd is one of repeat_last (default), cycle or not_implemented
+	SequenceEnd string `json:"sequence_end,omitempty"`
+	// Template enables rendering of body and header values as text/template with request data
+	Template bool `json:"template,omitempty"`
+	// DelayMs is delay before response. Uniform distribution picks delay from [DelayMs, DelayMaxMs],
+	// normal one uses DelayMs as mean with DelayStddevMs deviation.
+	DelayMs           uint   `json:"delay_ms,omitempty"`
+	DelayDistribution string `json:"delay_distribution,omitempty"`
+	DelayMaxMs        uint   `json:"delay_max_ms,omitempty"`
+	DelayStddevMs     uint   `json:"delay_stddev_ms,omitempty"`
+	// Fault is one of empty_response, connection_reset, headers_then_hang, truncated_body or garbage
+	Fault string `json:"fault,omitempty"`
+	// Alternatives of permanent response are chosen randomly by weight
+	Alternatives []Alternative `json:"alternatives,omitempty"`
+	// TTLSeconds is lifetime of response, expired response doesn't match and is deleted
+	TTLSeconds uint `json:"ttl_seconds,omitempty"`
+}
+
+type Alternative struct {
+	Weight uint `json:"weight"`
+	ResponseVariant
+}
+
+type ResponseVariant struct {
+	Status     uint    `json:"status"`
+	Headers    Headers `json:"headers"`
+	Body       string  `json:"body"`
+	BodyBase64 string  `json:"body_base64,omitempty"`
+	Fault      string  `json:"fault,omitempty"`
+	DelayMs    uint    `json:"delay_ms,omitempty"`
+}
+
+// ValueMatcher matches query param or header value.
+// Empty matcher requires value to be present.
+type ValueMatcher struct {
+	Equal  string `json:"equal,omitempty"`
+	Regex  string `json:"regex,omitempty"`
+	Absent bool   `json:"absent,omitempty"`
+}
+
+type QueryMatcher struct {
+	Params map[string]ValueMatcher `json:"params"`
+	// Exact disallows params not listed in Params
+	Exact bool `json:"exact"`
+}
+
+// BodyMatcher matches JSON request body.
+type BodyMatcher struct {
+	// EqualJSON requires body to be equal JSON ignoring keys order and whitespaces
+	EqualJSON any `json:"equal_json,omitempty"`
+	// ContainsJSON requires body to contain all fields of value
+	ContainsJSON any `json:"contains_json,omitempty"`
+	// JSONPath maps expressions like $.items[0].id to expected values
+	JSONPath map[string]any `json:"json_path,omitempty"`
+}
+
+// RequestPattern matches captured requests.
+// Empty fields match any request.
+type RequestPattern struct {
+	Method       string                  `json:"method,omitempty"`
+	Path         string                  `json:"path,omitempty"`
+	PathRegex    string                  `json:"path_regex,omitempty"`
+	Query        *QueryMatcher           `json:"query,omitempty"`
+	MatchHeaders map[string]ValueMatcher `json:"match_headers,omitempty"`
+	MatchBody    *BodyMatcher            `json:"match_body,omitempty"`
+}
+
+// Times is expected number of requests, use Exactly, AtLeast or Never.
+type Times struct {
+	Mode  string `json:"mode"`
+	Count uint   `json:"count"`
+}
+
+func (t Times) String() string {
+	if t.Mode == "never" {
+		return "never"
+	}
+
+	return fmt.Sprintf("%s %d", strings.ReplaceAll(t.Mode, "_", " "), t.Count)
+}
+
+// Exactly expects n requests
+func Exactly(n uint) Times {
+	return Times{Mode: "exactly", Count: n}
+}
+
+// AtLeast expects n or more requests
+func AtLeast(n uint) Times {
+	return Times{Mode: "at_least", Count: n}
+}
+
+// Never expects no requests
+func Never() Times {
+	return Times{Mode: "never"}
+}
+
+type VerificationResult struct {
+	Passed bool `json:"passed"`
+	// Count is number of requests matching pattern
+	Count      int       `json:"count"`
+	Matched    []Request `json:"matched"`
+	Mismatched []Request `json:"mismatched"`
+}
+
+type OrderVerificationResult struct {
+	Passed bool `json:"passed"`
+	// Matched are requests matched by patterns, the first not matched pattern has index len(Matched)
+	Matched []Request `json:"matched"`
+	// Requests are all test requests in order they were received
+	Requests []Request `json:"requests"`
 }
 
+// ErrVerificationFailed is returned by Verify if number of matching requests is not expected
+var ErrVerificationFailed = errors.New("verification failed")
+
+// WaitOptions filter requests awaited by Wait.
+// Zero Count waits for one request, zero Timeout waits server default 5 seconds.
+type WaitOptions struct {
+	Count   int
+	Timeout time.Duration
+	Method  string
+	// Path may be a route template like in responses
+	Path string
+}
+
+const defaultWaitTimeout = 5 * time.Second
+
+// ErrWaitTimeout is returned by Wait if requests were not captured in time
+var ErrWaitTimeout = errors.New("wait timeout")
+
 type Client struct {
 	url  string
 	http *http.Client
@@ -87,7 +280,15 @@ func (c *Client) Put(ctx context.Context, responses ...Response) error {
 
 // Get requests by test id
 func (c *Client) Get(ctx context.Context, testID string) ([]Request, error) {
-	url := fmt.Sprintf("%s/_requests/%s", c.url, testID)
+	return c.getRequests(ctx, fmt.Sprintf("%s/_requests/%s", c.url, testID))
+}
+
+// Unmatched returns requests without matching response, all if test id is empty
+func (c *Client) Unmatched(ctx context.Context, testID string) ([]Request, error) {
+	return c.getRequests(ctx, fmt.Sprintf("%s/_unmatched?test_id=%s", c.url, url.QueryEscape(testID)))
+}
+
+func (c *Client) getRequests(ctx context.Context, url string) ([]Request, error) {
 	request, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
 	if err != nil {
 		return nil, err
@@ -139,3 +340,189 @@ func (c *Client) Clean(ctx context.Context, testID string) error {
 
 	return nil
 }
+
+// Seed random generator of delays and alternatives to make runs reproducible
+func (c *Client) Seed(ctx context.Context, seed uint64) error {
+	body, err := json.Marshal(map[string]uint64{"seed": seed})
+	if err != nil {
+		return err
+	}
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, c.url+"/_chaos/seed", bytes.NewBuffer(body))
+	if err != nil {
+		return err
+	}
+
+	request.Header.Add("Content-Type", "application/json")
+
+	response, err := c.http.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+	if response.StatusCode >= 400 {
+		return fmt.Errorf("http status: %d", response.StatusCode)
+	}
+
+	return nil
+}
+
+// Verify checks that number of test requests matching pattern is expected.
+// ErrVerificationFailed is returned along with result if it is not.
+func (c *Client) Verify(ctx context.Context, testID string, pattern RequestPattern, times Times) (*VerificationResult, error) {
+	body, err := json.Marshal(map[string]any{
+		"test_id": testID,
+		"pattern": pattern,
+		"times":   times,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, c.url+"/_verify", bytes.NewBuffer(body))
+	if err != nil {
+		return nil, err
+	}
+
+	request.Header.Add("Content-Type", "application/json")
+
+	response, err := c.http.Do(request)
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+	if response.StatusCode >= 400 {
+		return nil, fmt.Errorf("http status: %d", response.StatusCode)
+	}
+
+	data, err := io.ReadAll(response.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	result := VerificationResult{}
+	err = json.Unmarshal(data, &result)
+	if err != nil {
+		return nil, err
+	}
+
+	if !result.Passed {
+		return &result, fmt.Errorf("%w: expected %s, got %d", ErrVerificationFailed, times, result.Count)
+	}
+
+	return &result, nil
+}
+
+// VerifyOrder checks that test requests matching patterns occurred in the same order.
+// ErrVerificationFailed is returned along with result if they did not.
+func (c *Client) VerifyOrder(ctx context.Context, testID string, patterns ...RequestPattern) (*OrderVerificationResult, error) {
+	body, err := json.Marshal(map[string]any{
+		"test_id":  testID,
+		"patterns": patterns,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, c.url+"/_verify/order", bytes.NewBuffer(body))
+	if err != nil {
+		return nil, err
+	}
+
+	request.Header.Add("Content-Type", "application/json")
+
+	response, err := c.http.Do(request)
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+	if response.StatusCode >= 400 {
+		return nil, fmt.Errorf("http status: %d", response.StatusCode)
+	}
+
+	data, err := io.ReadAll(response.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	result := OrderVerificationResult{}
+	err = json.Unmarshal(data, &result)
+	if err != nil {
+		return nil, err
+	}
+
+	if !result.Passed {
+		missing := patterns[len(result.Matched)]
+		path := missing.Path
+		if missing.PathRegex != "" {
+			path = missing.PathRegex
+		}
+		return &result, fmt.Errorf("%w: pattern %d (%s %s) not found in order", ErrVerificationFailed, len(result.Matched), missing.Method, path)
+	}
+
+	return &result, nil
+}
+
+// Wait blocks until requests of test matching options are captured and returns them.
+// ErrWaitTimeout is returned along with requests captured so far on timeout.
+func (c *Client) Wait(ctx context.Context, testID string, options WaitOptions) ([]Request, error) {
+	query := url.Values{}
+	if options.Count > 0 {
+		query.Set("count", strconv.Itoa(options.Count))
+	}
+	if options.Timeout > 0 {
+		query.Set("timeout", options.Timeout.String())
+	}
+	if options.Method != "" {
+		query.Set("method", options.Method)
+	}
+	if options.Path != "" {
+		query.Set("path", options.Path)
+	}
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, fmt.Sprintf("%s/_requests/%s/wait?%s", c.url, testID, query.Encode()), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	request.Header.Add("Content-Type", "application/json")
+
+	// Client timeout must not interrupt waiting
+	client := *c.http
+	if client.Timeout > 0 {
+		timeout := options.Timeout
+		if timeout == 0 {
+			timeout = defaultWaitTimeout
+		}
+		client.Timeout += timeout
+	}
+
+	response, err := client.Do(request)
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+	if response.StatusCode >= 400 && response.StatusCode != http.StatusRequestTimeout {
+		return nil, fmt.Errorf("http status: %d", response.StatusCode)
+	}
+
+	body, err := io.ReadAll(response.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	r := struct {
+		Requests []Request `json:"requests"`
+	}{}
+
+	err = json.Unmarshal(body, &r)
+	if err != nil {
+		return nil, err
+	}
+
+	if response.StatusCode == http.StatusRequestTimeout {
+		return r.Requests, ErrWaitTimeout
+	}
+
+	return r.Requests, nil
+}
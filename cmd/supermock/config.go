@@ -3,16 +3,25 @@ package main
 import (
 	"log/slog"
 	"strings"
+	"time"
 
 	"github.com/caarlos0/env/v11"
 )
 
 type Config struct {
-	LogLevel  string `env:"LOG_LEVEL" envDefault:"info"`
-	HttpAddr  string `env:"HTTP_ADDR" envDefault:"127.0.0.1:8000"`
-	DB        string `env:"DB" envDefault:"sqlite://db.sqlite3"`
-	SmtpAddr  string `env:"SMTP_ADDR"`
-	SmtpDebug bool   `env:"SMTP_DEBUG"`
+	LogLevel        string `env:"LOG_LEVEL" envDefault:"info"`
+	HttpAddr        string `env:"HTTP_ADDR" envDefault:"127.0.0.1:8000"`
+	DB              string `env:"DB" envDefault:"sqlite://db.sqlite3"`
+	SmtpAddr        string `env:"SMTP_ADDR"`
+	SmtpDebug       bool   `env:"SMTP_DEBUG"`
+	TestIDHeader    string `env:"TEST_ID_HEADER" envDefault:"X-Supermock-Test-ID"`
+	TestIDQuery     string `env:"TEST_ID_QUERY" envDefault:"supermock_test_id"`
+	UnmatchedStatus int    `env:"UNMATCHED_STATUS" envDefault:"501"`
+	UnmatchedBody   string `env:"UNMATCHED_BODY"`
+	// Retention of data, zero keeps it forever
+	RequestsRetention  time.Duration `env:"REQUESTS_RETENTION"`
+	ResponsesRetention time.Duration `env:"RESPONSES_RETENTION"`
+	EmailsRetention    time.Duration `env:"EMAILS_RETENTION"`
 }
 
 func (c *Config) slogLevel() slog.Level {
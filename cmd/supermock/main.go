@@ -45,7 +45,17 @@ func main() {
 		},
 	})))
 
-	supermock, err := app.New(config.HttpAddr, config.DB, config.SmtpAddr)
+	supermock, err := app.New(
+		config.HttpAddr,
+		config.DB,
+		config.SmtpAddr,
+		app.WithTestIDHeader(config.TestIDHeader),
+		app.WithTestIDQuery(config.TestIDQuery),
+		app.WithUnmatchedResponse(config.UnmatchedStatus, config.UnmatchedBody),
+		app.WithRequestsRetention(config.RequestsRetention),
+		app.WithResponsesRetention(config.ResponsesRetention),
+		app.WithEmailsRetention(config.EmailsRetention),
+	)
 	if err != nil {
 		slog.Error(err.Error())
 		return
@@ -19,19 +19,25 @@ type Email = models.Email
 
 type Supermock struct {
 	httpAddr string
-	db       *db.DB
+	db       db.DB
 	server   *echo.Echo
 	smtp     *SMTP
+	janitor  *janitor
 }
 
-func New(httpAddr, dbDSN, smtpAddr string) (*Supermock, error) {
+func New(httpAddr, dbDSN, smtpAddr string, opts ...Option) (*Supermock, error) {
+	o := defaultOptions()
+	for _, opt := range opts {
+		opt(&o)
+	}
+
 	db, err := db.New(dbDSN)
 	if err != nil {
 		return nil, fmt.Errorf("connect to db error: %w", err)
 	}
 
 	smtp := newSMTP(smtpAddr)
-	h := handlers.New(db, smtp)
+	h := handlers.New(db, smtp, o.handlers)
 
 	server := echo.New()
 	server.HideBanner = true
@@ -42,8 +48,14 @@ func New(httpAddr, dbDSN, smtpAddr string) (*Supermock, error) {
 	server.GET("/_responses", h.ResponseList)
 	server.DELETE("/_responses/:uuid", h.DeleteResponse)
 	server.GET("/_requests/:test_id", h.Requests)
+	server.GET("/_requests/:test_id/wait", h.Wait)
 	server.GET("/_requests", h.Requests)
+	server.GET("/_unmatched", h.Unmatched)
+	server.DELETE("/_unmatched", h.UnmatchedClean)
 	server.DELETE("/_tests/:test_id", h.Clean)
+	server.POST("/_chaos/seed", h.ChaosSeed)
+	server.POST("/_verify", h.Verify)
+	server.POST("/_verify/order", h.VerifyOrder)
 	server.Any("/*", h.Catch)
 
 	if smtp != nil {
@@ -56,6 +68,7 @@ func New(httpAddr, dbDSN, smtpAddr string) (*Supermock, error) {
 		db:       db,
 		server:   server,
 		smtp:     smtp,
+		janitor:  newJanitor(db, smtp, o.retention),
 	}, nil
 }
 
@@ -74,6 +87,8 @@ func (s *Supermock) Run() error {
 		}
 	}
 
+	s.janitor.Start()
+
 	slog.Info(fmt.Sprintf("Listen http://%s ...", s.httpAddr))
 	if err := s.server.Start(s.httpAddr); err != nil {
 		return err
@@ -97,6 +112,8 @@ func (s *Supermock) Stop() {
 		slog.Error(err.Error())
 	}
 
+	s.janitor.Stop()
+
 	s.db.Close()
 }
 
@@ -105,5 +122,5 @@ func (s *Supermock) Put(ctx context.Context, responses ...Response) error {
 }
 
 func (s *Supermock) Get(ctx context.Context, testID string) ([]Request, error) {
-	return s.db.Requests(ctx, testID)
+	return s.db.Requests(ctx, models.RequestFilter{TestID: testID})
 }
@@ -0,0 +1,110 @@
+package app
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/onrik/supermock/pkg/db"
+)
+
+// janitor periodically deletes expired responses and data older than retention.
+type janitor struct {
+	db        db.DB
+	smtp      *SMTP
+	retention retention
+	interval  time.Duration
+
+	stop chan struct{}
+	wg   sync.WaitGroup
+}
+
+// retention is how long data is kept, zero keeps forever.
+type retention struct {
+	requests  time.Duration
+	responses time.Duration
+	emails    time.Duration
+}
+
+func newJanitor(db db.DB, smtp *SMTP, r retention) *janitor {
+	return &janitor{
+		db:        db,
+		smtp:      smtp,
+		retention: r,
+		interval:  janitorInterval(r),
+		stop:      make(chan struct{}),
+	}
+}
+
+// janitorInterval is a minute or half of the shortest retention, but not less than a second.
+func janitorInterval(r retention) time.Duration {
+	interval := time.Minute
+	for _, d := range []time.Duration{r.requests, r.responses, r.emails} {
+		if d > 0 && d/2 < interval {
+			interval = d / 2
+		}
+	}
+
+	return max(interval, time.Second)
+}
+
+func (j *janitor) Start() {
+	j.wg.Add(1)
+	go func() {
+		defer j.wg.Done()
+
+		ticker := time.NewTicker(j.interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				j.sweep(context.Background())
+			case <-j.stop:
+				return
+			}
+		}
+	}()
+}
+
+func (j *janitor) Stop() {
+	close(j.stop)
+	j.wg.Wait()
+}
+
+func (j *janitor) sweep(ctx context.Context) {
+	now := time.Now()
+
+	n, err := j.db.DeleteExpiredResponses(ctx, now)
+	if err != nil {
+		slog.Error("Delete expired responses error", "error", err)
+	} else if n > 0 {
+		slog.Info("Expired responses deleted", "count", n)
+	}
+
+	if j.retention.responses > 0 {
+		n, err := j.db.DeleteResponsesBefore(ctx, now.Add(-j.retention.responses))
+		if err != nil {
+			slog.Error("Delete old responses error", "error", err)
+		} else if n > 0 {
+			slog.Info("Old responses deleted", "count", n)
+		}
+	}
+
+	if j.retention.requests > 0 {
+		n, err := j.db.DeleteRequestsBefore(ctx, now.Add(-j.retention.requests))
+		if err != nil {
+			slog.Error("Delete old requests error", "error", err)
+		} else if n > 0 {
+			slog.Info("Old requests deleted", "count", n)
+		}
+	}
+
+	if j.smtp != nil && j.retention.emails > 0 {
+		n := j.smtp.DeleteBefore(now.Add(-j.retention.emails))
+		if n > 0 {
+			slog.Info("Old emails deleted", "count", n)
+		}
+	}
+}
@@ -0,0 +1,69 @@
+package app
+
+import (
+	"time"
+
+	"github.com/onrik/supermock/pkg/handlers"
+)
+
+type options struct {
+	handlers  handlers.Config
+	retention retention
+}
+
+type Option func(*options)
+
+func defaultOptions() options {
+	return options{
+		handlers: handlers.Config{
+			TestIDHeader:    handlers.DefaultTestIDHeader,
+			TestIDQuery:     handlers.DefaultTestIDQuery,
+			UnmatchedStatus: handlers.DefaultUnmatchedStatus,
+		},
+	}
+}
+
+// WithTestIDHeader sets request header used to route requests to test responses.
+// Empty header disables routing by header.
+func WithTestIDHeader(header string) Option {
+	return func(o *options) {
+		o.handlers.TestIDHeader = header
+	}
+}
+
+// WithTestIDQuery sets query param used when test id header is not set.
+// Empty param disables routing by query.
+func WithTestIDQuery(param string) Option {
+	return func(o *options) {
+		o.handlers.TestIDQuery = param
+	}
+}
+
+// WithUnmatchedResponse sets status and body returned for requests without matching response.
+func WithUnmatchedResponse(status int, body string) Option {
+	return func(o *options) {
+		o.handlers.UnmatchedStatus = status
+		o.handlers.UnmatchedBody = body
+	}
+}
+
+// WithRequestsRetention deletes requests older than d. Zero keeps them forever.
+func WithRequestsRetention(d time.Duration) Option {
+	return func(o *options) {
+		o.retention.requests = d
+	}
+}
+
+// WithResponsesRetention deletes responses older than d, permanent ones too. Zero keeps them forever.
+func WithResponsesRetention(d time.Duration) Option {
+	return func(o *options) {
+		o.retention.responses = d
+	}
+}
+
+// WithEmailsRetention deletes emails older than d. Zero keeps them forever.
+func WithEmailsRetention(d time.Duration) Option {
+	return func(o *options) {
+		o.retention.emails = d
+	}
+}
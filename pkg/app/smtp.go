@@ -8,6 +8,8 @@ import (
 	"net"
 	"net/mail"
 	"strconv"
+	"sync"
+	"time"
 
 	smtpmock "github.com/mocktools/go-smtp-mock/v2"
 	"github.com/onrik/supermock/pkg/models"
@@ -41,6 +43,15 @@ func parseEmail(s string) (models.Email, error) {
 type SMTP struct {
 	addr   string
 	server *smtpmock.Server
+
+	// Messages are moved from server to emails to remember when they were received
+	mu     sync.Mutex
+	emails []receivedEmail
+}
+
+type receivedEmail struct {
+	email      models.Email
+	receivedAt time.Time
 }
 
 func newSMTP(addr string) *SMTP {
@@ -77,20 +88,57 @@ func (s *SMTP) Stop() error {
 	return s.server.Stop()
 }
 
-func (s *SMTP) Emails() []models.Email {
-	emails := []models.Email{}
-	for _, m := range s.server.Messages() {
+// collect moves new messages from server to emails, caller must hold the lock.
+func (s *SMTP) collect() {
+	now := time.Now()
+	for _, m := range s.server.MessagesAndPurge() {
 		email, err := parseEmail(m.MsgRequest())
 		if err != nil {
 			slog.Error("Parse email error", "error", err)
 		}
 
-		emails = append(emails, email)
+		s.emails = append(s.emails, receivedEmail{email, now})
+	}
+}
+
+func (s *SMTP) Emails() []models.Email {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.collect()
+
+	emails := []models.Email{}
+	for _, e := range s.emails {
+		emails = append(emails, e.email)
 	}
 
 	return emails
 }
 
 func (s *SMTP) Purge() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
 	s.server.MessagesAndPurge()
+	s.emails = nil
+}
+
+// DeleteBefore deletes emails received before t.
+// Receive time is the time email was first collected, so it may be late up to janitor interval.
+func (s *SMTP) DeleteBefore(t time.Time) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.collect()
+
+	emails := []receivedEmail{}
+	for _, e := range s.emails {
+		if !e.receivedAt.Before(t) {
+			emails = append(emails, e)
+		}
+	}
+	deleted := len(s.emails) - len(emails)
+	s.emails = emails
+
+	return deleted
 }
@@ -4,16 +4,28 @@ import (
 	"errors"
 	"fmt"
 	"reflect"
+	"regexp"
 	"strconv"
 	"strings"
 
 	v10 "github.com/go-playground/validator/v10"
+	"github.com/onrik/supermock/pkg/matcher"
 )
 
 type Validator struct{}
 
 func (Validator) Validate(i interface{}) error {
-	err := v10.New().Struct(i)
+	validate := v10.New()
+	err := validate.RegisterValidation("regexp", isRegexp)
+	if err != nil {
+		return err
+	}
+	err = validate.RegisterValidation("jsonpath", isJSONPath)
+	if err != nil {
+		return err
+	}
+
+	err = validate.Struct(i)
 	if err == nil {
 		return nil
 	}
@@ -53,6 +65,15 @@ func prepareNamespace(namespace string) []string {
 // Build path of error with json tags
 func buildPath(objectType reflect.Type, namespace []string) string {
 	field := namespace[0]
+	if objectType.Kind() == reflect.Ptr {
+		objectType = objectType.Elem()
+	}
+	if objectType.Kind() == reflect.Map {
+		if len(namespace) > 1 {
+			return field + "." + buildPath(objectType.Elem(), namespace[1:])
+		}
+		return field
+	}
 	_, err := strconv.Atoi(field)
 	if err == nil {
 		if len(namespace) > 1 {
@@ -60,11 +81,9 @@ func buildPath(objectType reflect.Type, namespace []string) string {
 		}
 		return field
 	}
-	var f reflect.StructField
-	if objectType.Kind() == reflect.Ptr {
-		f, _ = objectType.Elem().FieldByName(field)
-	} else {
-		f, _ = objectType.FieldByName(field)
+	f, _ := objectType.FieldByName(field)
+	if f.Anonymous && len(namespace) > 1 {
+		return buildPath(f.Type, namespace[1:])
 	}
 	tag := getJSONTag(f.Tag)
 	path := tag
@@ -75,6 +94,21 @@ func buildPath(objectType reflect.Type, namespace []string) string {
 	return path
 }
 
+// getJSONTag returns json name of field, query name for query params
 func getJSONTag(tag reflect.StructTag) string {
-	return strings.Split(tag.Get("json"), ",")[0]
+	name := strings.Split(tag.Get("json"), ",")[0]
+	if name == "" {
+		name = tag.Get("query")
+	}
+
+	return name
+}
+
+func isRegexp(fl v10.FieldLevel) bool {
+	_, err := regexp.Compile(fl.Field().String())
+	return err == nil
+}
+
+func isJSONPath(fl v10.FieldLevel) bool {
+	return matcher.ValidateJSONPath(fl.Field().String()) == nil
 }
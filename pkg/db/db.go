@@ -7,16 +7,38 @@ import (
 	"fmt"
 	"log/slog"
 	"net/url"
+	"strings"
 	"time"
 
 	"github.com/onrik/supermock/pkg/models"
 )
 
-type DB struct {
-	sql *sql.DB
+// DB stores requests and responses.
+type DB interface {
+	Requests(ctx context.Context, filter models.RequestFilter) ([]models.Request, error)
+	Response(ctx context.Context, request models.Request) (*models.Response, error)
+	Responses(ctx context.Context) ([]models.Response, error)
+	ResponseDelete(ctx context.Context, uuid string) error
+	ResponseSave(ctx context.Context, response models.Response) error
+	ResponsesSave(ctx context.Context, responses ...models.Response) error
+	SaveRequest(ctx context.Context, request models.Request) (int64, error)
+	UpdateRequestResponse(ctx context.Context, request models.Request) error
+	UnmatchedClean(ctx context.Context) error
+	Clean(ctx context.Context, testID string) error
+	DeleteExpiredResponses(ctx context.Context, now time.Time) (int64, error)
+	DeleteResponsesBefore(ctx context.Context, t time.Time) (int64, error)
+	DeleteRequestsBefore(ctx context.Context, t time.Time) (int64, error)
+	Close()
 }
 
-func New(dsn string) (*DB, error) {
+// SQL stores data in sqlite or postgres.
+type SQL struct {
+	sql    *sql.DB
+	driver string
+}
+
+// New connects to storage by dsn scheme: sqlite, postgres or memory.
+func New(dsn string) (DB, error) {
 	parsedDSN, err := url.Parse(dsn)
 	if err != nil {
 		return nil, err
@@ -24,27 +46,152 @@ func New(dsn string) (*DB, error) {
 
 	if parsedDSN.Scheme == "sqlite3" || parsedDSN.Scheme == "sqlite" {
 		db, err := initSqlite(*parsedDSN)
-		return &DB{db}, err
+		return &SQL{db, "sqlite3"}, err
 	} else if parsedDSN.Scheme == "postgres" {
 		db, err := initPostgresql(*parsedDSN)
-		return &DB{db}, err
+		return &SQL{db, "postgres"}, err
+	} else if parsedDSN.Scheme == "memory" {
+		return NewMemory(), nil
 	}
 	return nil, fmt.Errorf("unsupported dsn scheme: %s", parsedDSN.Scheme)
 }
 
-func (db *DB) Close() {
+// normalizeCreatedAt converts created_at saved by previous versions in RFC3339 UTC without
+// fractional seconds to models.TimeFormat, so times of all rows are compared as strings.
+func normalizeCreatedAt(db *sql.DB) error {
+	for _, table := range []string{"requests", "responses"} {
+		_, err := db.Exec(fmt.Sprintf("UPDATE %s SET created_at = substr(created_at, 1, 19) || '.000000Z' WHERE length(created_at) = 20", table))
+		if err != nil {
+			return fmt.Errorf("normalize %s created_at error: %w", table, err)
+		}
+	}
+
+	return nil
+}
+
+func marshalJSON(v any) (string, error) {
+	data, err := json.Marshal(v)
+	return string(data), err
+}
+
+// unmarshalJSON skips empty values of columns added by migrations
+func unmarshalJSON(data string, v any) error {
+	if data == "" {
+		return nil
+	}
+
+	return json.Unmarshal([]byte(data), v)
+}
+
+func (db *SQL) Close() {
 	err := db.sql.Close()
 	if err != nil {
 		slog.Error("Close db error", "error", err)
 	}
 }
 
-func (db *DB) Requests(ctx context.Context, testID string) ([]models.Request, error) {
-	sql := "SELECT test_id, method, path, query, headers, body, created_at FROM requests"
+const requestColumns = "test_id, method, path, query, headers, body, body_base64, created_at, response_uuid, response_status, response_headers, response_body, response_body_base64, response_fault, latency_ms, unmatched"
+
+func scanRequest(rows *sql.Rows) (models.Request, error) {
+	request := models.Request{
+		Headers: models.Headers{},
+	}
+	var headers, responseHeaders string
+	err := rows.Scan(
+		&request.Sequence,
+		&request.TestID,
+		&request.Method,
+		&request.Path,
+		&request.Query,
+		&headers,
+		&request.Body,
+		&request.BodyBase64,
+		&request.CreatedAt,
+		&request.ResponseUUID,
+		&request.ResponseStatus,
+		&responseHeaders,
+		&request.ResponseBody,
+		&request.ResponseBodyBase64,
+		&request.ResponseFault,
+		&request.LatencyMs,
+		&request.Unmatched,
+	)
+	if err != nil {
+		return request, err
+	}
+
+	err = json.Unmarshal([]byte(headers), &request.Headers)
+	if err != nil {
+		return request, err
+	}
+
+	err = unmarshalJSON(responseHeaders, &request.ResponseHeaders)
+	if err != nil {
+		return request, err
+	}
+
+	return request, nil
+}
+
+// Requests returns captured requests selected by filter in order of sequence.
+func (db *SQL) Requests(ctx context.Context, filter models.RequestFilter) ([]models.Request, error) {
+	where := []string{}
 	args := []any{}
-	if testID != "" {
-		sql += " WHERE test_id = $1"
-		args = append(args, testID)
+	add := func(condition string, arg any) {
+		args = append(args, arg)
+		where = append(where, fmt.Sprintf(condition, len(args)))
+	}
+
+	if filter.TestID != "" {
+		add("test_id = $%d", filter.TestID)
+	}
+	add("unmatched = $%d", filter.Unmatched)
+	if filter.Method != "" {
+		add("method = $%d", filter.Method)
+	}
+	// LIKE is case insensitive on sqlite, string functions compare case sensitive like memory storage
+	position := "strpos"
+	if db.driver == "sqlite3" {
+		position = "instr"
+	}
+	if filter.PathPrefix != "" {
+		add("substr(path, 1, length($%[1]d)) = $%[1]d", filter.PathPrefix)
+	}
+	if filter.Header != "" {
+		add(position+"(headers, $%d) > 0", filter.Header)
+	}
+	if filter.Body != "" {
+		add(position+"(body, $%d) > 0", filter.Body)
+	}
+	if !filter.From.IsZero() {
+		add("created_at >= $%d", filter.From.UTC().Format(models.TimeFormat))
+	}
+	if !filter.To.IsZero() {
+		add("created_at < $%d", filter.To.UTC().Format(models.TimeFormat))
+	}
+
+	order := "ASC"
+	if filter.Sort == models.SortDesc {
+		order = "DESC"
+	}
+	if filter.Cursor > 0 {
+		if order == "ASC" {
+			add("id > $%d", filter.Cursor)
+		} else {
+			add("id < $%d", filter.Cursor)
+		}
+	}
+
+	sql := "SELECT id, " + requestColumns + " FROM requests"
+	if len(where) > 0 {
+		sql += " WHERE " + strings.Join(where, " AND ")
+	}
+	sql += " ORDER BY id " + order
+	if filter.Limit > 0 {
+		sql += fmt.Sprintf(" LIMIT %d", filter.Limit)
+	}
+	if filter.Offset > 0 {
+		sql += fmt.Sprintf(" OFFSET %d", filter.Offset)
 	}
 
 	rows, err := db.sql.QueryContext(ctx, sql, args...)
@@ -55,16 +202,7 @@ func (db *DB) Requests(ctx context.Context, testID string) ([]models.Request, er
 
 	requests := []models.Request{}
 	for rows.Next() {
-		request := models.Request{
-			Headers: map[string]string{},
-		}
-		var headers string
-		err = rows.Scan(&request.TestID, &request.Method, &request.Path, &request.Query, &headers, &request.Body, &request.CreatedAt)
-		if err != nil {
-			return nil, err
-		}
-
-		err = json.Unmarshal([]byte(headers), &request.Headers)
+		request, err := scanRequest(rows)
 		if err != nil {
 			return nil, err
 		}
@@ -75,21 +213,65 @@ func (db *DB) Requests(ctx context.Context, testID string) ([]models.Request, er
 	return requests, nil
 }
 
-func (db *DB) SaveRequest(ctx context.Context, request models.Request) error {
+func (db *SQL) UnmatchedClean(ctx context.Context) error {
+	_, err := db.sql.ExecContext(ctx, "DELETE FROM requests WHERE unmatched = $1", true)
+	return err
+}
+
+// SaveRequest saves request and returns its sequence number.
+func (db *SQL) SaveRequest(ctx context.Context, request models.Request) (int64, error) {
 	headers, err := json.Marshal(request.Headers)
+	if err != nil {
+		return 0, err
+	}
+
+	responseHeaders, err := marshalJSON(request.ResponseHeaders)
+	if err != nil {
+		return 0, err
+	}
+
+	if request.CreatedAt == "" {
+		request.CreatedAt = time.Now().UTC().Format(models.TimeFormat)
+	}
+
+	var id int64
+	err = db.sql.QueryRowContext(
+		ctx,
+		"INSERT INTO requests ("+requestColumns+") VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16) RETURNING id",
+		request.TestID, request.Method, request.Path, request.Query, string(headers), request.Body, request.BodyBase64, request.CreatedAt,
+		request.ResponseUUID, request.ResponseStatus, responseHeaders, request.ResponseBody, request.ResponseBodyBase64, request.ResponseFault, request.LatencyMs, request.Unmatched,
+	).Scan(&id)
+
+	return id, err
+}
+
+// UpdateRequestResponse sets response that was sent and latency of saved request.
+func (db *SQL) UpdateRequestResponse(ctx context.Context, request models.Request) error {
+	responseHeaders, err := marshalJSON(request.ResponseHeaders)
 	if err != nil {
 		return err
 	}
-	request.CreatedAt = time.Now().UTC().Format(time.RFC3339)
 
-	_, err = db.sql.Exec(
-		"INSERT INTO requests (test_id, method, path, query, headers, body, created_at) VALUES ($1, $2, $3, $4, $5, $6, $7)",
-		request.TestID, request.Method, request.Path, request.Query, string(headers), request.Body, request.CreatedAt)
+	_, err = db.sql.ExecContext(
+		ctx,
+		"UPDATE requests SET response_status = $1, response_headers = $2, response_body = $3, response_body_base64 = $4, response_fault = $5, latency_ms = $6 WHERE id = $7",
+		request.ResponseStatus, responseHeaders, request.ResponseBody, request.ResponseBodyBase64, request.ResponseFault, request.LatencyMs, request.Sequence,
+	)
 
 	return err
 }
 
-func (db *DB) Clean(ctx context.Context, testID string) error {
+// DeleteRequestsBefore deletes requests received before t.
+func (db *SQL) DeleteRequestsBefore(ctx context.Context, t time.Time) (int64, error) {
+	result, err := db.sql.ExecContext(ctx, "DELETE FROM requests WHERE created_at < $1", t.UTC().Format(models.TimeFormat))
+	if err != nil {
+		return 0, err
+	}
+
+	return result.RowsAffected()
+}
+
+func (db *SQL) Clean(ctx context.Context, testID string) error {
 	_, err := db.sql.ExecContext(ctx, "DELETE FROM requests WHERE test_id = $1", testID)
 	if err != nil {
 		return err
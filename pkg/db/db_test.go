@@ -0,0 +1,86 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/onrik/supermock/pkg/models"
+)
+
+// TestRequestsFilter checks that text filters are case sensitive on all storages.
+// Postgres is tested when TEST_POSTGRES_DSN is set.
+func TestRequestsFilter(t *testing.T) {
+	dsns := []string{"sqlite://:memory:", "memory://"}
+	if dsn := os.Getenv("TEST_POSTGRES_DSN"); dsn != "" {
+		dsns = append(dsns, dsn)
+	}
+
+	for _, dsn := range dsns {
+		u, err := url.Parse(dsn)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		t.Run(u.Scheme, func(t *testing.T) {
+			testRequestsFilter(t, dsn)
+		})
+	}
+}
+
+func testRequestsFilter(t *testing.T, dsn string) {
+	db, err := New(dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	ctx := context.Background()
+	testID := fmt.Sprintf("filter-%d", time.Now().UnixNano())
+	defer func() {
+		_ = db.Clean(ctx, testID)
+	}()
+
+	for _, request := range []models.Request{
+		{TestID: testID, Method: "GET", Path: "/Users/1", Headers: models.Headers{"X-Token": {"Abc"}}, Body: "Hello"},
+		{TestID: testID, Method: "GET", Path: "/users_%/2", Headers: models.Headers{"X-Token": {"abc"}}, Body: "hello"},
+	} {
+		_, err = db.SaveRequest(ctx, request)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name   string
+		filter models.RequestFilter
+		paths  []string
+	}{
+		{"path prefix", models.RequestFilter{PathPrefix: "/Users"}, []string{"/Users/1"}},
+		{"path prefix wildcards", models.RequestFilter{PathPrefix: "/users_%"}, []string{"/users_%/2"}},
+		{"path prefix is not pattern", models.RequestFilter{PathPrefix: "/user%"}, nil},
+		{"header", models.RequestFilter{Header: "Abc"}, []string{"/Users/1"}},
+		{"body", models.RequestFilter{Body: "hello"}, []string{"/users_%/2"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.filter.TestID = testID
+			requests, err := db.Requests(ctx, tt.filter)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			paths := []string{}
+			for _, request := range requests {
+				paths = append(paths, request.Path)
+			}
+			if fmt.Sprint(paths) != fmt.Sprint(tt.paths) {
+				t.Errorf("paths = %v, expected %v", paths, tt.paths)
+			}
+		})
+	}
+}
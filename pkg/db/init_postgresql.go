@@ -42,7 +42,41 @@ func initPostgresql(dsn url.URL) (*sql.DB, error) {
 	);
 
 	ALTER TABLE requests ADD COLUMN IF NOT EXISTS query TEXT NOT NULL DEFAULT '';
+	ALTER TABLE requests ADD COLUMN IF NOT EXISTS body_base64 TEXT NOT NULL DEFAULT '';
+	ALTER TABLE requests ADD COLUMN IF NOT EXISTS response_uuid TEXT NOT NULL DEFAULT '';
+	ALTER TABLE requests ADD COLUMN IF NOT EXISTS response_status INTEGER NOT NULL DEFAULT 0;
+	ALTER TABLE requests ADD COLUMN IF NOT EXISTS response_headers TEXT NOT NULL DEFAULT '';
+	ALTER TABLE requests ADD COLUMN IF NOT EXISTS response_body TEXT NOT NULL DEFAULT '';
+	ALTER TABLE requests ADD COLUMN IF NOT EXISTS response_body_base64 TEXT NOT NULL DEFAULT '';
+	ALTER TABLE requests ADD COLUMN IF NOT EXISTS response_fault TEXT NOT NULL DEFAULT '';
+	ALTER TABLE requests ADD COLUMN IF NOT EXISTS latency_ms INTEGER NOT NULL DEFAULT 0;
+	ALTER TABLE requests ADD COLUMN IF NOT EXISTS unmatched bool NOT NULL DEFAULT false;
+	ALTER TABLE responses ADD COLUMN IF NOT EXISTS path_regex TEXT NOT NULL DEFAULT '';
+	ALTER TABLE responses ADD COLUMN IF NOT EXISTS query TEXT NOT NULL DEFAULT '';
+	ALTER TABLE responses ADD COLUMN IF NOT EXISTS match_headers TEXT NOT NULL DEFAULT '';
+	ALTER TABLE responses ADD COLUMN IF NOT EXISTS match_body TEXT NOT NULL DEFAULT '';
+	ALTER TABLE responses ADD COLUMN IF NOT EXISTS times INTEGER NOT NULL DEFAULT 0;
+	ALTER TABLE responses ADD COLUMN IF NOT EXISTS hits INTEGER NOT NULL DEFAULT 0;
+	ALTER TABLE responses ADD COLUMN IF NOT EXISTS sequence TEXT NOT NULL DEFAULT '';
+	ALTER TABLE responses ADD COLUMN IF NOT EXISTS sequence_end TEXT NOT NULL DEFAULT '';
+	ALTER TABLE responses ADD COLUMN IF NOT EXISTS template bool NOT NULL DEFAULT false;
+	ALTER TABLE responses ADD COLUMN IF NOT EXISTS delay_ms INTEGER NOT NULL DEFAULT 0;
+	ALTER TABLE responses ADD COLUMN IF NOT EXISTS delay_distribution TEXT NOT NULL DEFAULT '';
+	ALTER TABLE responses ADD COLUMN IF NOT EXISTS delay_max_ms INTEGER NOT NULL DEFAULT 0;
+	ALTER TABLE responses ADD COLUMN IF NOT EXISTS delay_stddev_ms INTEGER NOT NULL DEFAULT 0;
+	ALTER TABLE responses ADD COLUMN IF NOT EXISTS fault TEXT NOT NULL DEFAULT '';
+	ALTER TABLE responses ADD COLUMN IF NOT EXISTS alternatives TEXT NOT NULL DEFAULT '';
+	ALTER TABLE responses ADD COLUMN IF NOT EXISTS body_base64 TEXT NOT NULL DEFAULT '';
+	ALTER TABLE responses ADD COLUMN IF NOT EXISTS expires_at TEXT NOT NULL DEFAULT '';
+
+	CREATE INDEX IF NOT EXISTS requests_test_id_idx ON requests (test_id);
+	CREATE INDEX IF NOT EXISTS responses_method_path_idx ON responses (method, path);
 `)
+	if err != nil {
+		return nil, err
+	}
+
+	err = normalizeCreatedAt(db)
 
 	return db, err
 }
@@ -26,7 +26,16 @@ func initSqlite(dsn url.URL) (*sql.DB, error) {
 		query TEXT NOT NULL,
 		headers TEXT NOT NULL,
 		body TEXT NOT NULL,
-		created_at TEXT NOT NULL
+		body_base64 TEXT NOT NULL DEFAULT '',
+		created_at TEXT NOT NULL,
+		response_uuid TEXT NOT NULL DEFAULT '',
+		response_status INTEGER NOT NULL DEFAULT 0,
+		response_headers TEXT NOT NULL DEFAULT '',
+		response_body TEXT NOT NULL DEFAULT '',
+		response_body_base64 TEXT NOT NULL DEFAULT '',
+		response_fault TEXT NOT NULL DEFAULT '',
+		latency_ms INTEGER NOT NULL DEFAULT 0,
+		unmatched bool NOT NULL DEFAULT false
 	);
 
 	CREATE TABLE IF NOT EXISTS responses (
@@ -35,14 +44,128 @@ func initSqlite(dsn url.URL) (*sql.DB, error) {
 		test_id TEXT NOT NULL,
 		method TEXT NOT NULL,
 		path TEXT NOT NULL,
+		path_regex TEXT NOT NULL DEFAULT '',
+		query TEXT NOT NULL DEFAULT '',
+		match_headers TEXT NOT NULL DEFAULT '',
+		match_body TEXT NOT NULL DEFAULT '',
 		status INTEGER NOT NULL,
 		headers TEXT NOT NULL,
 		body TEXT NOT NULL,
+		body_base64 TEXT NOT NULL DEFAULT '',
 		is_permanent bool NOT NULL,
 		disable_catch bool NOT NULL,
+		times INTEGER NOT NULL DEFAULT 0,
+		hits INTEGER NOT NULL DEFAULT 0,
+		sequence TEXT NOT NULL DEFAULT '',
+		sequence_end TEXT NOT NULL DEFAULT '',
+		template bool NOT NULL DEFAULT false,
+		delay_ms INTEGER NOT NULL DEFAULT 0,
+		delay_distribution TEXT NOT NULL DEFAULT '',
+		delay_max_ms INTEGER NOT NULL DEFAULT 0,
+		delay_stddev_ms INTEGER NOT NULL DEFAULT 0,
+		fault TEXT NOT NULL DEFAULT '',
+		alternatives TEXT NOT NULL DEFAULT '',
+		expires_at TEXT NOT NULL DEFAULT '',
 		created_at TEXT NOT NULL
 	);
+
+	CREATE INDEX IF NOT EXISTS requests_test_id_idx ON requests (test_id);
+	CREATE INDEX IF NOT EXISTS responses_method_path_idx ON responses (method, path);
 `)
+	if err != nil {
+		return nil, err
+	}
+
+	err = migrateSqlite(db)
+	if err != nil {
+		return nil, err
+	}
+
+	err = normalizeCreatedAt(db)
 
 	return db, err
 }
+
+// sqliteColumns are added to tables created by previous versions.
+var sqliteColumns = []struct {
+	table      string
+	column     string
+	definition string
+}{
+	{"responses", "path_regex", "TEXT NOT NULL DEFAULT ''"},
+	{"responses", "query", "TEXT NOT NULL DEFAULT ''"},
+	{"responses", "match_headers", "TEXT NOT NULL DEFAULT ''"},
+	{"responses", "match_body", "TEXT NOT NULL DEFAULT ''"},
+	{"responses", "times", "INTEGER NOT NULL DEFAULT 0"},
+	{"responses", "hits", "INTEGER NOT NULL DEFAULT 0"},
+	{"responses", "sequence", "TEXT NOT NULL DEFAULT ''"},
+	{"responses", "sequence_end", "TEXT NOT NULL DEFAULT ''"},
+	{"responses", "template", "bool NOT NULL DEFAULT false"},
+	{"responses", "delay_ms", "INTEGER NOT NULL DEFAULT 0"},
+	{"responses", "delay_distribution", "TEXT NOT NULL DEFAULT ''"},
+	{"responses", "delay_max_ms", "INTEGER NOT NULL DEFAULT 0"},
+	{"responses", "delay_stddev_ms", "INTEGER NOT NULL DEFAULT 0"},
+	{"responses", "fault", "TEXT NOT NULL DEFAULT ''"},
+	{"responses", "alternatives", "TEXT NOT NULL DEFAULT ''"},
+	{"requests", "body_base64", "TEXT NOT NULL DEFAULT ''"},
+	{"responses", "body_base64", "TEXT NOT NULL DEFAULT ''"},
+	{"requests", "response_uuid", "TEXT NOT NULL DEFAULT ''"},
+	{"requests", "response_status", "INTEGER NOT NULL DEFAULT 0"},
+	{"requests", "response_headers", "TEXT NOT NULL DEFAULT ''"},
+	{"requests", "response_body", "TEXT NOT NULL DEFAULT ''"},
+	{"requests", "response_body_base64", "TEXT NOT NULL DEFAULT ''"},
+	{"requests", "response_fault", "TEXT NOT NULL DEFAULT ''"},
+	{"requests", "latency_ms", "INTEGER NOT NULL DEFAULT 0"},
+	{"requests", "unmatched", "bool NOT NULL DEFAULT false"},
+	{"responses", "expires_at", "TEXT NOT NULL DEFAULT ''"},
+}
+
+// migrateSqlite adds missing columns, sqlite has no ADD COLUMN IF NOT EXISTS.
+func migrateSqlite(db *sql.DB) error {
+	columns := map[string]map[string]bool{}
+	for _, c := range sqliteColumns {
+		if columns[c.table] == nil {
+			existing, err := sqliteTableColumns(db, c.table)
+			if err != nil {
+				return err
+			}
+			columns[c.table] = existing
+		}
+
+		if columns[c.table][c.column] {
+			continue
+		}
+
+		_, err := db.Exec(fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", c.table, c.column, c.definition))
+		if err != nil {
+			return fmt.Errorf("add column %s.%s error: %w", c.table, c.column, err)
+		}
+		columns[c.table][c.column] = true
+	}
+
+	return nil
+}
+
+func sqliteTableColumns(db *sql.DB, table string) (map[string]bool, error) {
+	rows, err := db.Query(fmt.Sprintf("PRAGMA table_info(%s)", table))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	columns := map[string]bool{}
+	for rows.Next() {
+		var (
+			cid, notNull, pk int
+			name, typ        string
+			defaultValue     sql.NullString
+		)
+		err = rows.Scan(&cid, &name, &typ, &notNull, &defaultValue, &pk)
+		if err != nil {
+			return nil, err
+		}
+		columns[name] = true
+	}
+
+	return columns, rows.Err()
+}
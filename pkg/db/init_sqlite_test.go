@@ -0,0 +1,127 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/onrik/supermock/pkg/models"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// TestSqliteMigration checks that database created by the first version gets all columns
+// and created_at in models.TimeFormat.
+func TestSqliteMigration(t *testing.T) {
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = os.Chdir(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		_ = os.Chdir(wd)
+	}()
+
+	old, err := sql.Open("sqlite3", "file:old.sqlite3")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = old.Exec(`
+	CREATE TABLE requests (
+		id INTEGER NOT NULL PRIMARY KEY,
+		test_id TEXT NOT NULL,
+		method TEXT NOT NULL,
+		path TEXT NOT NULL,
+		query TEXT NOT NULL,
+		headers TEXT NOT NULL,
+		body TEXT NOT NULL,
+		created_at TEXT NOT NULL
+	);
+
+	CREATE TABLE responses (
+		id INTEGER NOT NULL PRIMARY KEY,
+		uuid TEXT NOT NULL,
+		test_id TEXT NOT NULL,
+		method TEXT NOT NULL,
+		path TEXT NOT NULL,
+		status INTEGER NOT NULL,
+		headers TEXT NOT NULL,
+		body TEXT NOT NULL,
+		is_permanent bool NOT NULL,
+		disable_catch bool NOT NULL,
+		created_at TEXT NOT NULL
+	);
+	INSERT INTO responses (uuid, test_id, method, path, status, headers, body, is_permanent, disable_catch, created_at)
+	VALUES ('old', 't', 'GET', '/old', 200, '{}', '', true, false, '2024-01-01T00:00:00Z');
+	INSERT INTO requests (test_id, method, path, query, headers, body, created_at)
+	VALUES ('old', 'GET', '/old', '', '{}', '', '2024-01-01T00:00:00Z');
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	old.Close()
+
+	db, err := New("sqlite://old.sqlite3")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	fresh, err := New("sqlite://fresh.sqlite3")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer fresh.Close()
+
+	for _, table := range []string{"requests", "responses"} {
+		migrated, err := sqliteTableColumns(db.(*SQL).sql, table)
+		if err != nil {
+			t.Fatal(err)
+		}
+		created, err := sqliteTableColumns(fresh.(*SQL).sql, table)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(migrated, created) {
+			t.Errorf("%s columns = %v, want %v", table, migrated, created)
+		}
+	}
+
+	ctx := context.Background()
+	response, err := db.Response(ctx, models.Request{Method: "GET", Path: "/old"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if response == nil || response.UUID != "old" {
+		t.Fatalf("old response not found: %+v", response)
+	}
+
+	err = db.ResponseSave(ctx, models.Response{UUID: "new", TestID: "t", Method: "GET", PathRegex: "^/new", Status: 200})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = db.SaveRequest(ctx, models.Request{TestID: "t", Method: "GET", Path: "/new", Headers: models.Headers{}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	requests, err := db.Requests(ctx, models.RequestFilter{TestID: "t"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(requests) != 1 {
+		t.Fatalf("requests = %d, want 1", len(requests))
+	}
+
+	requests, err = db.Requests(ctx, models.RequestFilter{TestID: "old"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(requests) != 1 || requests[0].CreatedAt != "2024-01-01T00:00:00.000000Z" {
+		t.Fatalf("old requests = %+v, want created_at in models.TimeFormat", requests)
+	}
+}
@@ -0,0 +1,343 @@
+package db
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/onrik/supermock/pkg/matcher"
+	"github.com/onrik/supermock/pkg/models"
+)
+
+// Memory stores data in process memory, it is used for memory:// dsn.
+// It needs neither cgo nor database and is handy for embedding in unit tests.
+type Memory struct {
+	mu         sync.Mutex
+	requests   []models.Request
+	responses  []memoryResponse
+	sequence   int64
+	responseID int64
+}
+
+type memoryResponse struct {
+	models.Response
+	createdAt string
+}
+
+func NewMemory() *Memory {
+	return &Memory{}
+}
+
+func (m *Memory) Close() {}
+
+// Requests returns captured requests selected by filter in order of sequence.
+func (m *Memory) Requests(ctx context.Context, filter models.RequestFilter) ([]models.Request, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	from := ""
+	if !filter.From.IsZero() {
+		from = filter.From.UTC().Format(models.TimeFormat)
+	}
+	to := ""
+	if !filter.To.IsZero() {
+		to = filter.To.UTC().Format(models.TimeFormat)
+	}
+
+	requests := []models.Request{}
+	for i := range m.requests {
+		request := m.requests[i]
+		if filter.Sort == models.SortDesc {
+			request = m.requests[len(m.requests)-1-i]
+		}
+
+		switch {
+		case filter.TestID != "" && request.TestID != filter.TestID,
+			request.Unmatched != filter.Unmatched,
+			filter.Method != "" && request.Method != filter.Method,
+			!strings.HasPrefix(request.Path, filter.PathPrefix),
+			!strings.Contains(request.Body, filter.Body),
+			from != "" && request.CreatedAt < from,
+			to != "" && request.CreatedAt >= to,
+			filter.Cursor > 0 && filter.Sort != models.SortDesc && request.Sequence <= filter.Cursor,
+			filter.Cursor > 0 && filter.Sort == models.SortDesc && request.Sequence >= filter.Cursor:
+			continue
+		}
+
+		if filter.Header != "" {
+			headers, err := json.Marshal(request.Headers)
+			if err != nil {
+				return nil, err
+			}
+			if !strings.Contains(string(headers), filter.Header) {
+				continue
+			}
+		}
+
+		requests = append(requests, cloneRequest(request))
+	}
+
+	requests = requests[min(filter.Offset, len(requests)):]
+	if filter.Limit > 0 && len(requests) > filter.Limit {
+		requests = requests[:filter.Limit]
+	}
+
+	return requests, nil
+}
+
+// SaveRequest saves request and returns its sequence number.
+func (m *Memory) SaveRequest(ctx context.Context, request models.Request) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if request.CreatedAt == "" {
+		request.CreatedAt = time.Now().UTC().Format(models.TimeFormat)
+	}
+
+	m.sequence++
+	request.Sequence = m.sequence
+	m.requests = append(m.requests, cloneRequest(request))
+
+	return request.Sequence, nil
+}
+
+// UpdateRequestResponse sets response that was sent and latency of saved request.
+func (m *Memory) UpdateRequestResponse(ctx context.Context, request models.Request) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	i := slices.IndexFunc(m.requests, func(r models.Request) bool {
+		return r.Sequence == request.Sequence
+	})
+	if i < 0 {
+		return nil
+	}
+
+	m.requests[i].ResponseStatus = request.ResponseStatus
+	m.requests[i].ResponseHeaders = cloneHeaders(request.ResponseHeaders)
+	m.requests[i].ResponseBody = request.ResponseBody
+	m.requests[i].ResponseBodyBase64 = request.ResponseBodyBase64
+	m.requests[i].ResponseFault = request.ResponseFault
+	m.requests[i].LatencyMs = request.LatencyMs
+
+	return nil
+}
+
+// Response finds response for request and consumes one use of it.
+// Not permanent response is deleted after last use.
+func (m *Memory) Response(ctx context.Context, request models.Request) (*models.Response, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now().UTC().Format(models.TimeFormat)
+	candidates := []models.Response{}
+	for _, r := range m.responses {
+		if r.Method != request.Method && r.Method != matcher.MethodAny {
+			continue
+		}
+		if r.ExpiresAt != "" && r.ExpiresAt <= now {
+			continue
+		}
+
+		response := r.Response
+		setRemaining(&response)
+		candidates = append(candidates, response)
+	}
+
+	response := matcher.Select(candidates, request)
+	if response == nil {
+		return nil, nil
+	}
+
+	response.Hits++
+	deleted := !response.IsPermanent && response.Hits >= maxHits(*response)
+	i := slices.IndexFunc(m.responses, func(r memoryResponse) bool {
+		return r.ID == response.ID
+	})
+	if deleted {
+		m.responses = slices.Delete(m.responses, i, i+1)
+	} else {
+		m.responses[i].Hits = response.Hits
+	}
+
+	if !response.IsPermanent {
+		response.Remaining--
+	}
+
+	if deleted {
+		slog.InfoContext(ctx, "Response deleted", "id", response.ID, "test_id", response.TestID)
+	}
+
+	result := cloneResponse(*response)
+
+	return &result, nil
+}
+
+func (m *Memory) Responses(ctx context.Context) ([]models.Response, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	responses := []models.Response{}
+	for _, r := range m.responses {
+		response := cloneResponse(r.Response)
+		setRemaining(&response)
+		responses = append(responses, response)
+	}
+
+	return responses, nil
+}
+
+func (m *Memory) ResponseDelete(ctx context.Context, uuid string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.responses = slices.DeleteFunc(m.responses, func(r memoryResponse) bool {
+		return r.UUID == uuid
+	})
+
+	return nil
+}
+
+func (m *Memory) ResponseSave(ctx context.Context, response models.Response) error {
+	now := time.Now().UTC()
+	err := prepareResponse(&response, now)
+	if err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.responseID++
+	response.ID = m.responseID
+	response.Hits = 0
+	response.Remaining = 0
+	m.responses = append(m.responses, memoryResponse{
+		Response:  cloneResponse(response),
+		createdAt: now.Format(models.TimeFormat),
+	})
+
+	return nil
+}
+
+func (m *Memory) ResponsesSave(ctx context.Context, responses ...models.Response) error {
+	for i := range responses {
+		err := m.ResponseSave(ctx, responses[i])
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (m *Memory) UnmatchedClean(ctx context.Context) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.requests = slices.DeleteFunc(m.requests, func(r models.Request) bool {
+		return r.Unmatched
+	})
+
+	return nil
+}
+
+func (m *Memory) Clean(ctx context.Context, testID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.requests = slices.DeleteFunc(m.requests, func(r models.Request) bool {
+		return r.TestID == testID
+	})
+	m.responses = slices.DeleteFunc(m.responses, func(r memoryResponse) bool {
+		return r.TestID == testID
+	})
+
+	return nil
+}
+
+// DeleteExpiredResponses deletes responses with TTL expired before now.
+func (m *Memory) DeleteExpiredResponses(ctx context.Context, now time.Time) (int64, error) {
+	limit := now.UTC().Format(models.TimeFormat)
+
+	return m.deleteResponses(func(r memoryResponse) bool {
+		return r.ExpiresAt != "" && r.ExpiresAt <= limit
+	}), nil
+}
+
+// DeleteResponsesBefore deletes responses created before t.
+func (m *Memory) DeleteResponsesBefore(ctx context.Context, t time.Time) (int64, error) {
+	limit := t.UTC().Format(models.TimeFormat)
+
+	return m.deleteResponses(func(r memoryResponse) bool {
+		return r.createdAt < limit
+	}), nil
+}
+
+// DeleteRequestsBefore deletes requests received before t.
+func (m *Memory) DeleteRequestsBefore(ctx context.Context, t time.Time) (int64, error) {
+	limit := t.UTC().Format(models.TimeFormat)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	n := len(m.requests)
+	m.requests = slices.DeleteFunc(m.requests, func(r models.Request) bool {
+		return r.CreatedAt < limit
+	})
+
+	return int64(n - len(m.requests)), nil
+}
+
+func (m *Memory) deleteResponses(del func(memoryResponse) bool) int64 {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	n := len(m.responses)
+	m.responses = slices.DeleteFunc(m.responses, del)
+
+	return int64(n - len(m.responses))
+}
+
+// cloneResponse copies response with its headers, so handlers can change the copy.
+// Matchers are shared as they are never changed.
+func cloneResponse(response models.Response) models.Response {
+	response.Headers = cloneHeaders(response.Headers)
+
+	response.Sequence = slices.Clone(response.Sequence)
+	for i := range response.Sequence {
+		response.Sequence[i].Headers = cloneHeaders(response.Sequence[i].Headers)
+	}
+
+	response.Alternatives = slices.Clone(response.Alternatives)
+	for i := range response.Alternatives {
+		response.Alternatives[i].Headers = cloneHeaders(response.Alternatives[i].Headers)
+	}
+
+	return response
+}
+
+// cloneRequest copies request with its headers, so stored request is not shared with handlers.
+func cloneRequest(request models.Request) models.Request {
+	request.Headers = cloneHeaders(request.Headers)
+	request.ResponseHeaders = cloneHeaders(request.ResponseHeaders)
+
+	return request
+}
+
+func cloneHeaders(headers models.Headers) models.Headers {
+	if headers == nil {
+		return nil
+	}
+
+	clone := make(models.Headers, len(headers))
+	for k, v := range headers {
+		clone[k] = slices.Clone(v)
+	}
+
+	return clone
+}
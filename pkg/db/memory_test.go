@@ -0,0 +1,46 @@
+package db
+
+import (
+	"context"
+	"testing"
+
+	"github.com/onrik/supermock/pkg/models"
+)
+
+// TestMemoryRequestHeaders checks that saved request does not share headers with callers.
+func TestMemoryRequestHeaders(t *testing.T) {
+	db := NewMemory()
+	ctx := context.Background()
+
+	request := models.Request{
+		TestID:          "t",
+		Method:          "GET",
+		Path:            "/users",
+		Headers:         models.Headers{"X-Token": {"a"}},
+		ResponseHeaders: models.Headers{"Content-Type": {"text/plain"}},
+	}
+	_, err := db.SaveRequest(ctx, request)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	request.Headers["X-Token"][0] = "changed"
+	request.ResponseHeaders["Content-Type"] = []string{"changed"}
+
+	requests, err := db.Requests(ctx, models.RequestFilter{TestID: "t"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	requests[0].Headers["X-Token"] = []string{"changed"}
+
+	requests, err = db.Requests(ctx, models.RequestFilter{TestID: "t"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if token := requests[0].Headers["X-Token"][0]; token != "a" {
+		t.Errorf("X-Token = %q, expected %q", token, "a")
+	}
+	if contentType := requests[0].ResponseHeaders["Content-Type"][0]; contentType != "text/plain" {
+		t.Errorf("Content-Type = %q, expected %q", contentType, "text/plain")
+	}
+}
@@ -1,64 +1,269 @@
 package db
 
 import (
+	"bytes"
 	"context"
+	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log/slog"
+	"strings"
 	"time"
 
+	"github.com/onrik/supermock/pkg/matcher"
 	"github.com/onrik/supermock/pkg/models"
 )
 
-func (db *DB) Response(ctx context.Context, method, path string) (*models.Response, error) {
-	rows, err := db.sql.QueryContext(
-		ctx,
-		"SELECT id, test_id, status, headers, body, is_permanent, disable_catch FROM responses WHERE method = $1 AND path = $2 ORDER BY id ASC LIMIT 1",
-		method, path)
+const responseColumns = "id, uuid, test_id, method, path, path_regex, query, match_headers, match_body, status, headers, body, body_base64, is_permanent, disable_catch, times, hits, sequence, sequence_end, template, delay_ms, delay_distribution, delay_max_ms, delay_stddev_ms, fault, alternatives, expires_at"
+
+func scanResponse(rows *sql.Rows) (models.Response, error) {
+	response := models.Response{
+		Headers: models.Headers{},
+	}
+	var query, matchHeaders, matchBody, headers, sequence, alternatives string
+	err := rows.Scan(
+		&response.ID,
+		&response.UUID,
+		&response.TestID,
+		&response.Method,
+		&response.Path,
+		&response.PathRegex,
+		&query,
+		&matchHeaders,
+		&matchBody,
+		&response.Status,
+		&headers,
+		&response.Body,
+		&response.BodyBase64,
+		&response.IsPermanent,
+		&response.DisableCatch,
+		&response.Times,
+		&response.Hits,
+		&sequence,
+		&response.SequenceEnd,
+		&response.Template,
+		&response.DelayMs,
+		&response.DelayDistribution,
+		&response.DelayMaxMs,
+		&response.DelayStddevMs,
+		&response.Fault,
+		&alternatives,
+		&response.ExpiresAt,
+	)
 	if err != nil {
-		return nil, fmt.Errorf("query error: %w", err)
+		return response, fmt.Errorf("scan error: %w", err)
 	}
 
-	defer rows.Close()
+	setRemaining(&response)
+
+	if len(headers) > 0 {
+		err = json.Unmarshal([]byte(headers), &response.Headers)
+		if err != nil {
+			return response, fmt.Errorf("unmarshal headers error: %w", err)
+		}
+	}
+
+	err = unmarshalJSON(query, &response.Query)
+	if err != nil {
+		return response, fmt.Errorf("unmarshal query error: %w", err)
+	}
 
-	if !rows.Next() {
-		return nil, nil
+	err = unmarshalJSON(matchHeaders, &response.MatchHeaders)
+	if err != nil {
+		return response, fmt.Errorf("unmarshal match headers error: %w", err)
 	}
 
-	response := models.Response{
-		Headers: map[string]string{},
+	err = unmarshalJSON(matchBody, &response.MatchBody)
+	if err != nil {
+		return response, fmt.Errorf("unmarshal match body error: %w", err)
+	}
+
+	err = unmarshalJSON(sequence, &response.Sequence)
+	if err != nil {
+		return response, fmt.Errorf("unmarshal sequence error: %w", err)
+	}
+
+	err = unmarshalJSON(alternatives, &response.Alternatives)
+	if err != nil {
+		return response, fmt.Errorf("unmarshal alternatives error: %w", err)
+	}
+
+	return response, nil
+}
+
+// maxHits returns number of times not permanent response can be served.
+func maxHits(response models.Response) uint {
+	if response.Times > 0 {
+		return response.Times
+	}
+
+	if len(response.Sequence) > 0 {
+		return uint(len(response.Sequence))
+	}
+
+	return 1
+}
+
+// setRemaining sets number of uses left for not permanent response.
+func setRemaining(response *models.Response) {
+	if !response.IsPermanent {
+		response.Remaining = maxHits(*response) - response.Hits
+	}
+}
+
+// maxResponseRetries limits selections repeated because of concurrent requests.
+const maxResponseRetries = 10
+
+// querier is implemented by sql.DB and sql.Tx.
+type querier interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+// Response finds response for request and consumes one use of it.
+// Not permanent response is deleted after last use.
+// Candidates are read without locks, only the selected row is changed and only if nobody
+// has consumed it meanwhile, otherwise selection is repeated.
+// So concurrent requests never get the same use of response and do not wait for each other.
+// After maxResponseRetries selection runs in transaction with candidates locked on postgres,
+// sqlite transactions are serialized by the single connection.
+func (db *SQL) Response(ctx context.Context, request models.Request) (*models.Response, error) {
+	for i := 0; i < maxResponseRetries; i++ {
+		response, ok, err := db.selectResponse(ctx, db.sql, request, "")
+		if err != nil || ok {
+			return response, err
+		}
+	}
+
+	tx, err := db.sql.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("begin error: %w", err)
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	lock := ""
+	if db.driver == "postgres" {
+		lock = " FOR UPDATE"
 	}
-	var headers string
-	err = rows.Scan(&response.ID, &response.TestID, &response.Status, &headers, &response.Body, &response.IsPermanent, &response.DisableCatch)
+
+	response, ok, err := db.selectResponse(ctx, tx, request, lock)
 	if err != nil {
 		return nil, err
 	}
+	if !ok {
+		return nil, fmt.Errorf("locked response %d is consumed concurrently", response.ID)
+	}
 
-	if len(headers) > 0 {
-		err = json.Unmarshal([]byte(headers), &response.Headers)
+	err = tx.Commit()
+	if err != nil {
+		return nil, fmt.Errorf("commit error: %w", err)
+	}
+
+	return response, nil
+}
+
+// selectResponse selects response for request and consumes it.
+// It returns false if selected response was consumed by concurrent request.
+func (db *SQL) selectResponse(ctx context.Context, q querier, request models.Request, lock string) (*models.Response, bool, error) {
+	candidates, err := responseCandidates(ctx, q, request.Method, lock)
+	if err != nil {
+		return nil, false, err
+	}
+
+	response := matcher.Select(candidates, request)
+	if response == nil {
+		return nil, true, nil
+	}
+
+	deleted := !response.IsPermanent && response.Hits+1 >= maxHits(*response)
+	ok, err := consumeResponse(ctx, q, response, deleted)
+	if err != nil || !ok {
+		return response, false, err
+	}
+
+	if !response.IsPermanent {
+		response.Remaining--
+	}
+
+	if deleted {
+		slog.InfoContext(ctx, "Response deleted", "id", response.ID, "test_id", response.TestID)
+	}
+
+	return response, true, nil
+}
+
+func responseCandidates(ctx context.Context, q querier, method, lock string) ([]models.Response, error) {
+	now := time.Now().UTC().Format(models.TimeFormat)
+	rows, err := q.QueryContext(
+		ctx,
+		"SELECT "+responseColumns+" FROM responses WHERE (method = $1 OR method = $2) AND (expires_at = '' OR expires_at > $3) ORDER BY id ASC"+lock,
+		method, matcher.MethodAny, now,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("query error: %w", err)
+	}
+
+	defer rows.Close()
+
+	candidates := []models.Response{}
+	for rows.Next() {
+		response, err := scanResponse(rows)
 		if err != nil {
 			return nil, err
 		}
+
+		candidates = append(candidates, response)
 	}
 
-	rows.Close()
+	return candidates, rows.Err()
+}
 
-	if !response.IsPermanent {
-		_, err = db.sql.ExecContext(ctx, "DELETE FROM responses WHERE id = $1", response.ID)
+// consumeResponse counts one use of response or deletes it after last use.
+// Uses of not permanent response and of sequence depend on hits, so they are counted
+// only if hits are unchanged. Plain permanent response is counted unconditionally.
+// It returns false if response was consumed or deleted by concurrent request.
+func consumeResponse(ctx context.Context, q querier, response *models.Response, deleted bool) (bool, error) {
+	if deleted {
+		result, err := q.ExecContext(ctx, "DELETE FROM responses WHERE id = $1 AND hits = $2", response.ID, response.Hits)
 		if err != nil {
-			return nil, fmt.Errorf("delete error: %w", err)
+			return false, fmt.Errorf("delete error: %w", err)
 		}
 
-		slog.InfoContext(ctx, "Response deleted", "id", response.ID, "test_id", response.TestID)
+		n, err := result.RowsAffected()
+		if err != nil || n == 0 {
+			return false, err
+		}
+
+		response.Hits++
+		return true, nil
 	}
 
-	return &response, nil
+	query := "UPDATE responses SET hits = hits + 1 WHERE id = $1 AND hits = $2 RETURNING hits"
+	args := []any{response.ID, response.Hits}
+	if response.IsPermanent && len(response.Sequence) == 0 {
+		query = "UPDATE responses SET hits = hits + 1 WHERE id = $1 RETURNING hits"
+		args = args[:1]
+	}
+
+	err := q.QueryRowContext(ctx, query, args...).Scan(&response.Hits)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("update error: %w", err)
+	}
+
+	return true, nil
 }
 
-func (db *DB) Responses(ctx context.Context) ([]models.Response, error) {
+func (db *SQL) Responses(ctx context.Context) ([]models.Response, error) {
 	rows, err := db.sql.QueryContext(
 		ctx,
-		"SELECT id, test_id, method, path, status, headers, body, is_permanent, disable_catch FROM responses",
+		"SELECT "+responseColumns+" FROM responses",
 	)
 	if err != nil {
 		return nil, fmt.Errorf("query error: %w", err)
@@ -68,30 +273,9 @@ func (db *DB) Responses(ctx context.Context) ([]models.Response, error) {
 
 	responses := []models.Response{}
 	for rows.Next() {
-		response := models.Response{
-			Headers: map[string]string{},
-		}
-		var headers string
-		err = rows.Scan(
-			&response.ID,
-			&response.TestID,
-			&response.Method,
-			&response.Path,
-			&response.Status,
-			&headers,
-			&response.Body,
-			&response.IsPermanent,
-			&response.DisableCatch,
-		)
+		response, err := scanResponse(rows)
 		if err != nil {
-			return nil, fmt.Errorf("scan error: %w", err)
-		}
-
-		if len(headers) > 0 {
-			err = json.Unmarshal([]byte(headers), &response.Headers)
-			if err != nil {
-				slog.ErrorContext(ctx, "Unmarshal response headers error", "error", err, "response", response, "headers", headers)
-			}
+			return nil, err
 		}
 
 		responses = append(responses, response)
@@ -100,27 +284,117 @@ func (db *DB) Responses(ctx context.Context) ([]models.Response, error) {
 	return responses, nil
 }
 
-func (db *DB) ResponseDelete(ctx context.Context, uuid string) error {
+func (db *SQL) ResponseDelete(ctx context.Context, uuid string) error {
 	_, err := db.sql.ExecContext(ctx, "DELETE FROM responses WHERE uuid = $1", uuid)
 	return err
 }
 
-func (db *DB) ResponseSave(ctx context.Context, response models.Response) error {
-	if response.Headers == nil {
-		response.Headers = map[string]string{}
+func (db *SQL) ResponseSave(ctx context.Context, response models.Response) error {
+	now := time.Now().UTC()
+	err := prepareResponse(&response, now)
+	if err != nil {
+		return err
 	}
+
 	headers, err := json.Marshal(response.Headers)
 	if err != nil {
 		return err
 	}
 
+	query, err := marshalJSON(response.Query)
+	if err != nil {
+		return err
+	}
+
+	matchHeaders, err := marshalJSON(response.MatchHeaders)
+	if err != nil {
+		return err
+	}
+
+	matchBody, err := marshalJSON(response.MatchBody)
+	if err != nil {
+		return err
+	}
+
+	sequence, err := marshalJSON(response.Sequence)
+	if err != nil {
+		return err
+	}
+
+	alternatives, err := marshalJSON(response.Alternatives)
+	if err != nil {
+		return err
+	}
+
 	_, err = db.sql.Exec(
-		"INSERT INTO responses (uuid, test_id, method, path, status, headers, body, is_permanent, disable_catch, created_at) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)",
-		response.UUID, response.TestID, response.Method, response.Path, response.Status, string(headers), response.Body, response.IsPermanent, response.DisableCatch, time.Now().UTC().Format(time.RFC3339))
+		"INSERT INTO responses (uuid, test_id, method, path, path_regex, query, match_headers, match_body, status, headers, body, body_base64, is_permanent, disable_catch, times, hits, sequence, sequence_end, template, delay_ms, delay_distribution, delay_max_ms, delay_stddev_ms, fault, alternatives, expires_at, created_at) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, 0, $16, $17, $18, $19, $20, $21, $22, $23, $24, $25, $26)",
+		response.UUID, response.TestID, response.Method, response.Path, response.PathRegex, query, matchHeaders, matchBody, response.Status, string(headers), response.Body, response.BodyBase64, response.IsPermanent, response.DisableCatch, response.Times, sequence, response.SequenceEnd, response.Template, response.DelayMs, response.DelayDistribution, response.DelayMaxMs, response.DelayStddevMs, response.Fault, alternatives, response.ExpiresAt, now.Format(models.TimeFormat))
 	return err
 }
 
-func (db *DB) ResponsesSave(ctx context.Context, responses ...models.Response) error {
+// DeleteExpiredResponses deletes responses with TTL expired before now.
+func (db *SQL) DeleteExpiredResponses(ctx context.Context, now time.Time) (int64, error) {
+	result, err := db.sql.ExecContext(ctx, "DELETE FROM responses WHERE expires_at <> '' AND expires_at <= $1", now.UTC().Format(models.TimeFormat))
+	if err != nil {
+		return 0, err
+	}
+
+	return result.RowsAffected()
+}
+
+// DeleteResponsesBefore deletes responses created before t.
+func (db *SQL) DeleteResponsesBefore(ctx context.Context, t time.Time) (int64, error) {
+	result, err := db.sql.ExecContext(ctx, "DELETE FROM responses WHERE created_at < $1", t.UTC().Format(models.TimeFormat))
+	if err != nil {
+		return 0, err
+	}
+
+	return result.RowsAffected()
+}
+
+// prepareResponse sets defaults, JSON body and expiration time before save.
+func prepareResponse(response *models.Response, now time.Time) error {
+	if response.Headers == nil {
+		response.Headers = models.Headers{}
+	}
+
+	if len(response.BodyJSON) > 0 {
+		err := setJSONBody(response)
+		if err != nil {
+			return err
+		}
+	}
+
+	response.ExpiresAt = ""
+	if response.TTLSeconds > 0 {
+		response.ExpiresAt = now.Add(time.Duration(response.TTLSeconds) * time.Second).UTC().Format(models.TimeFormat)
+	}
+
+	return nil
+}
+
+// setJSONBody moves body_json to body and sets JSON content type if no one is given.
+func setJSONBody(response *models.Response) error {
+	body := bytes.Buffer{}
+	err := json.Compact(&body, response.BodyJSON)
+	if err != nil {
+		return fmt.Errorf("invalid body_json: %w", err)
+	}
+
+	response.Body = body.String()
+	response.BodyJSON = nil
+
+	for k := range response.Headers {
+		if strings.EqualFold(k, "Content-Type") {
+			return nil
+		}
+	}
+	response.Headers["Content-Type"] = []string{"application/json"}
+
+	return nil
+}
+
+func (db *SQL) ResponsesSave(ctx context.Context, responses ...models.Response) error {
 	for i := range responses {
 		err := db.ResponseSave(ctx, responses[i])
 		if err != nil {
@@ -0,0 +1,187 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"os"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/onrik/supermock/pkg/models"
+
+	_ "github.com/lib/pq"
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// TestResponseConcurrent checks that concurrent requests get distinct queued responses
+// and queue is served in order, permanent response counts every hit.
+// Postgres is tested when TEST_POSTGRES_DSN is set.
+func TestResponseConcurrent(t *testing.T) {
+	dsns := []string{"sqlite://:memory:", "memory://"}
+	if dsn := os.Getenv("TEST_POSTGRES_DSN"); dsn != "" {
+		dsns = append(dsns, dsn)
+	}
+
+	for _, dsn := range dsns {
+		u, err := url.Parse(dsn)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		t.Run(u.Scheme, func(t *testing.T) {
+			testResponseConcurrent(t, dsn)
+		})
+	}
+}
+
+func testResponseConcurrent(t *testing.T, dsn string) {
+	const n = 100
+
+	db, err := New(dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	ctx := context.Background()
+	testID := fmt.Sprintf("concurrent-%d", time.Now().UnixNano())
+	defer func() {
+		_ = db.Clean(ctx, testID)
+	}()
+
+	for i := 0; i < n; i++ {
+		err = db.ResponseSave(ctx, models.Response{
+			UUID:   fmt.Sprintf("%s-%d", testID, i),
+			TestID: testID,
+			Method: "POST",
+			Path:   "/concurrent",
+			Status: 200,
+			Body:   strconv.Itoa(i),
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	request := models.Request{
+		TestID: testID,
+		Method: "POST",
+		Path:   "/concurrent",
+	}
+
+	uuids := make(chan string, n)
+	errs := make(chan error, n)
+	wg := sync.WaitGroup{}
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			response, err := db.Response(ctx, request)
+			if err != nil {
+				errs <- err
+				return
+			}
+			if response == nil {
+				errs <- fmt.Errorf("no response")
+				return
+			}
+			uuids <- response.UUID
+		}()
+	}
+	wg.Wait()
+	close(uuids)
+	close(errs)
+
+	for err := range errs {
+		t.Error(err)
+	}
+
+	served := map[string]bool{}
+	for uuid := range uuids {
+		if served[uuid] {
+			t.Errorf("response %s served twice", uuid)
+		}
+		served[uuid] = true
+	}
+	if len(served) != n {
+		t.Errorf("served %d responses, expected %d", len(served), n)
+	}
+
+	response, err := db.Response(ctx, request)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if response != nil {
+		t.Errorf("response %s left in queue", response.UUID)
+	}
+
+	// Queue is served in order of creation.
+	for i := 0; i < n; i++ {
+		err = db.ResponseSave(ctx, models.Response{
+			UUID:   fmt.Sprintf("%s-fifo-%d", testID, i),
+			TestID: testID,
+			Method: "POST",
+			Path:   "/concurrent",
+			Status: 200,
+			Body:   strconv.Itoa(i),
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for i := 0; i < n; i++ {
+		response, err := db.Response(ctx, request)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if response == nil {
+			t.Fatalf("no response %d", i)
+		}
+		if response.Body != strconv.Itoa(i) {
+			t.Fatalf("response body = %s, expected %d", response.Body, i)
+		}
+	}
+
+	// Permanent response counts all concurrent hits.
+	err = db.ResponseSave(ctx, models.Response{
+		UUID:        testID + "-permanent",
+		TestID:      testID,
+		Method:      "POST",
+		Path:        "/concurrent",
+		Status:      200,
+		IsPermanent: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	errs = make(chan error, n)
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := db.Response(ctx, request)
+			if err != nil {
+				errs <- err
+			}
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		t.Error(err)
+	}
+
+	response, err = db.Response(ctx, request)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if response == nil || response.Hits != n+1 {
+		t.Errorf("permanent response = %+v, expected %d hits", response, n+1)
+	}
+}
@@ -0,0 +1,28 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/base64"
+	"unicode/utf8"
+
+	"github.com/onrik/supermock/pkg/models"
+)
+
+// setRequestBody saves binary body as base64, so it can be stored in text column.
+func setRequestBody(request *models.Request, body []byte) {
+	if utf8.Valid(body) && bytes.IndexByte(body, 0) < 0 {
+		request.Body = string(body)
+		return
+	}
+
+	request.BodyBase64 = base64.StdEncoding.EncodeToString(body)
+}
+
+// responseBody returns response body decoding binary one.
+func responseBody(response *models.Response) ([]byte, error) {
+	if response.BodyBase64 != "" {
+		return base64.StdEncoding.DecodeString(response.BodyBase64)
+	}
+
+	return []byte(response.Body), nil
+}
@@ -0,0 +1,51 @@
+package handlers
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestResponseCreateBodyJSON(t *testing.T) {
+	tests := []struct {
+		name        string
+		headers     string
+		contentType string
+	}{
+		{"default content type", `{}`, "application/json"},
+		{"own content type", `{"content-type": "application/problem+json"}`, "application/problem+json"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, _ := newTestServer(t, Config{TestIDHeader: DefaultTestIDHeader})
+
+			status, body := do(t, http.MethodPost, server.URL+"/_responses", "", `{
+				"uuid": "j", "test_id": "t", "method": "GET", "path": "/user", "status": 200,
+				"headers": `+tt.headers+`,
+				"body_json": {"name": "Bob", "tags": [1, 2]}
+			}`)
+			if status != http.StatusOK {
+				t.Fatalf("create = %d %s", status, body)
+			}
+
+			req, err := http.NewRequest(http.MethodGet, server.URL+"/user", nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			req.Header.Set(DefaultTestIDHeader, "t")
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp.Body.Close()
+			if contentType := resp.Header.Get("Content-Type"); contentType != tt.contentType {
+				t.Errorf("Content-Type = %q, expected %q", contentType, tt.contentType)
+			}
+
+			requests := getRequests(t, server, "t")
+			if len(requests) != 1 || requests[0].ResponseBody != `{"name":"Bob","tags":[1,2]}` {
+				t.Errorf("requests = %+v, expected compact JSON response body", requests)
+			}
+		})
+	}
+}
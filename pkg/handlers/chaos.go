@@ -0,0 +1,54 @@
+package handlers
+
+import (
+	"log/slog"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/onrik/supermock/pkg/models"
+)
+
+// applyAlternative replaces response with randomly chosen alternative according to weights.
+func applyAlternative(response *models.Response, rnd *random) {
+	total := uint(0)
+	for _, alternative := range response.Alternatives {
+		total += alternative.Weight
+	}
+	if total == 0 {
+		return
+	}
+
+	n := rnd.UintN(total)
+	for _, alternative := range response.Alternatives {
+		if n < alternative.Weight {
+			applyVariant(response, alternative.ResponseVariant)
+			return
+		}
+		n -= alternative.Weight
+	}
+}
+
+type chaosSeed struct {
+	Seed uint64 `json:"seed"`
+}
+
+/*
+ChaosSeed
+@openapi POST /_chaos/seed
+@openapiSummary Seed random generator of delays and alternatives to make runs reproducible
+@openapiRequest application/json {"seed": 42}
+@openapiResponse 200 application/json {}
+*/
+func (h *Handlers) ChaosSeed(c echo.Context) error {
+	seed := chaosSeed{}
+	err := c.Bind(&seed)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err)
+	}
+
+	h.random.Seed(seed.Seed)
+
+	slog.Info("Random seeded", "seed", seed.Seed)
+
+	return c.JSON(http.StatusOK, echo.Map{})
+}
@@ -0,0 +1,64 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/onrik/supermock/pkg/models"
+)
+
+func TestCatchAlternatives(t *testing.T) {
+	server, storage := newTestServer(t, Config{TestIDHeader: DefaultTestIDHeader})
+	putResponses(t, storage, models.Response{
+		UUID:        "a",
+		TestID:      "t",
+		Method:      "GET",
+		Path:        "/flaky",
+		IsPermanent: true,
+		Alternatives: []models.Alternative{
+			{Weight: 3, ResponseVariant: models.ResponseVariant{Status: 200, Body: "ok"}},
+			{Weight: 1, ResponseVariant: models.ResponseVariant{Status: 503, Body: "unavailable"}},
+		},
+	})
+
+	run := func() []int {
+		status, body := do(t, http.MethodPost, server.URL+"/_chaos/seed", "", `{"seed": 42}`)
+		if status != http.StatusOK {
+			t.Fatalf("seed = %d %s", status, body)
+		}
+
+		statuses := []int{}
+		for range 100 {
+			status, body := do(t, http.MethodGet, server.URL+"/flaky", "t", "")
+			if (status != 200 || body != "ok") && (status != 503 || body != "unavailable") {
+				t.Fatalf("response = %d %s, expected one of alternatives", status, body)
+			}
+			statuses = append(statuses, status)
+		}
+
+		return statuses
+	}
+
+	first := run()
+	second := run()
+	if fmt.Sprint(first) != fmt.Sprint(second) {
+		t.Errorf("statuses with the same seed differ:\n%v\n%v", first, second)
+	}
+
+	failed := 0
+	for _, status := range first {
+		if status == 503 {
+			failed++
+		}
+	}
+	// Weight of 503 is 1/4, bounds are loose to not depend on generator
+	if failed < 10 || failed > 40 {
+		t.Errorf("503 count = %d of %d, expected about 25", failed, len(first))
+	}
+
+	requests := getRequests(t, server, "t")
+	if len(requests) != 200 || requests[0].ResponseStatus != uint16(first[0]) {
+		t.Errorf("requests = %d, expected 200 with chosen alternative", len(requests))
+	}
+}
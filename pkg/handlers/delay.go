@@ -0,0 +1,44 @@
+package handlers
+
+import (
+	"context"
+	"time"
+
+	"github.com/onrik/supermock/pkg/models"
+)
+
+// responseDelay returns delay of response by its distribution.
+func responseDelay(response *models.Response, rnd *random) time.Duration {
+	ms := float64(response.DelayMs)
+	switch response.DelayDistribution {
+	case models.DelayUniform:
+		if response.DelayMaxMs > response.DelayMs {
+			ms += rnd.Float64() * float64(response.DelayMaxMs-response.DelayMs)
+		}
+	case models.DelayNormal:
+		ms += rnd.NormFloat64() * float64(response.DelayStddevMs)
+	}
+
+	if ms <= 0 {
+		return 0
+	}
+
+	return time.Duration(ms * float64(time.Millisecond))
+}
+
+// sleep waits for delay or context cancellation.
+func sleep(ctx context.Context, delay time.Duration) error {
+	if delay <= 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
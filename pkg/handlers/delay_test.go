@@ -0,0 +1,77 @@
+package handlers
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/onrik/supermock/pkg/models"
+)
+
+func TestCatchDelay(t *testing.T) {
+	tests := []struct {
+		name     string
+		response models.Response
+		min      time.Duration
+		max      time.Duration
+	}{
+		{"fixed", models.Response{Status: 200, DelayMs: 100}, 100 * time.Millisecond, time.Second},
+		{"uniform", models.Response{Status: 200, DelayMs: 50, DelayMaxMs: 80, DelayDistribution: models.DelayUniform}, 50 * time.Millisecond, time.Second},
+		{"sequence variant", models.Response{Sequence: []models.ResponseVariant{{Status: 200, DelayMs: 100}}}, 100 * time.Millisecond, time.Second},
+		{"no delay", models.Response{Status: 200}, 0, 50 * time.Millisecond},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, storage := newTestServer(t, Config{TestIDHeader: DefaultTestIDHeader})
+			tt.response.UUID = "d"
+			tt.response.TestID = "t"
+			tt.response.Method = "GET"
+			tt.response.Path = "/slow"
+			putResponses(t, storage, tt.response)
+
+			start := time.Now()
+			status, _ := do(t, http.MethodGet, server.URL+"/slow", "t", "")
+			elapsed := time.Since(start)
+			if status != http.StatusOK {
+				t.Fatalf("status = %d, expected %d", status, http.StatusOK)
+			}
+			if elapsed < tt.min || elapsed > tt.max {
+				t.Errorf("elapsed = %s, expected between %s and %s", elapsed, tt.min, tt.max)
+			}
+
+			requests := getRequests(t, server, "t")
+			if len(requests) != 1 || requests[0].LatencyMs < tt.min.Milliseconds() {
+				t.Errorf("requests = %+v, expected latency at least %d ms", requests, tt.min.Milliseconds())
+			}
+		})
+	}
+}
+
+// TestCatchDelayCaptured checks that delayed request is visible before response is sent.
+func TestCatchDelayCaptured(t *testing.T) {
+	server, storage := newTestServer(t, Config{TestIDHeader: DefaultTestIDHeader})
+	putResponses(t, storage, models.Response{UUID: "d", TestID: "t", Method: "GET", Path: "/slow", Status: 200, DelayMs: 500})
+
+	done := make(chan int)
+	go func() {
+		req, _ := http.NewRequest(http.MethodGet, server.URL+"/slow", nil)
+		req.Header.Set(DefaultTestIDHeader, "t")
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			done <- 0
+			return
+		}
+		resp.Body.Close()
+		done <- resp.StatusCode
+	}()
+
+	status, body := do(t, http.MethodGet, server.URL+"/_requests/t/wait?timeout=400ms", "", "")
+	if status != http.StatusOK {
+		t.Errorf("wait = %d %s, expected request captured during delay", status, body)
+	}
+
+	if status := <-done; status != http.StatusOK {
+		t.Errorf("status = %d, expected %d", status, http.StatusOK)
+	}
+}
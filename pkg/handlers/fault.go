@@ -0,0 +1,117 @@
+package handlers
+
+import (
+	"bufio"
+	"crypto/rand"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/onrik/supermock/pkg/models"
+)
+
+const (
+	// hangTimeout limits headers_then_hang fault if client never closes connection
+	hangTimeout = 10 * time.Minute
+	// garbageSize is number of random bytes sent by garbage fault
+	garbageSize = 1024
+	// truncatedBodyMissing is number of bytes declared in Content-Length but never sent
+	truncatedBodyMissing = 64
+)
+
+// writeFault breaks connection instead of normal response.
+func writeFault(c echo.Context, response *models.Response) error {
+	body, err := responseBody(response)
+	if err != nil {
+		return err
+	}
+
+	conn, rw, err := c.Response().Hijack()
+	if err != nil {
+		return fmt.Errorf("hijack error: %w", err)
+	}
+	defer conn.Close()
+
+	switch response.Fault {
+	case models.FaultEmptyResponse:
+		return nil
+	case models.FaultConnectionReset:
+		if tcp, ok := conn.(*net.TCPConn); ok {
+			// Close with RST instead of FIN
+			return tcp.SetLinger(0)
+		}
+		return nil
+	case models.FaultHeadersThenHang:
+		err = writeHead(rw, response, len(body))
+		if err != nil {
+			return err
+		}
+		// Wait until client closes connection
+		err = conn.SetReadDeadline(time.Now().Add(hangTimeout))
+		if err != nil {
+			return err
+		}
+		_, _ = io.Copy(io.Discard, conn)
+		return nil
+	case models.FaultTruncatedBody:
+		err = writeHead(rw, response, len(body)+truncatedBodyMissing)
+		if err != nil {
+			return err
+		}
+		_, err = rw.Write(body)
+		if err != nil {
+			return err
+		}
+		return rw.Flush()
+	case models.FaultGarbage:
+		garbage := make([]byte, garbageSize)
+		_, err = rand.Read(garbage)
+		if err != nil {
+			return err
+		}
+		_, err = rw.Write(garbage)
+		if err != nil {
+			return err
+		}
+		return rw.Flush()
+	}
+
+	return fmt.Errorf("unknown fault: %s", response.Fault)
+}
+
+// writeHead writes status line and headers with given Content-Length.
+func writeHead(rw *bufio.ReadWriter, response *models.Response, contentLength int) error {
+	status := int(response.Status)
+	if status == 0 {
+		status = http.StatusOK
+	}
+
+	header := http.Header{}
+	for k, values := range response.Headers {
+		for _, v := range values {
+			header.Add(k, v)
+		}
+	}
+	header.Set("Content-Length", strconv.Itoa(contentLength))
+
+	_, err := fmt.Fprintf(rw, "HTTP/1.1 %d %s\r\n", status, http.StatusText(status))
+	if err != nil {
+		return err
+	}
+
+	err = header.Write(rw)
+	if err != nil {
+		return err
+	}
+
+	_, err = rw.WriteString("\r\n")
+	if err != nil {
+		return err
+	}
+
+	return rw.Flush()
+}
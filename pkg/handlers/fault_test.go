@@ -0,0 +1,77 @@
+package handlers
+
+import (
+	"io"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/onrik/supermock/pkg/models"
+)
+
+func TestCatchFault(t *testing.T) {
+	tests := []struct {
+		fault string
+		// status is sent before connection breaks, 0 means no response at all
+		status int
+	}{
+		{models.FaultEmptyResponse, 0},
+		{models.FaultConnectionReset, 0},
+		{models.FaultGarbage, 0},
+		{models.FaultTruncatedBody, 200},
+		{models.FaultHeadersThenHang, 200},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.fault, func(t *testing.T) {
+			server, storage := newTestServer(t, Config{TestIDHeader: DefaultTestIDHeader})
+			putResponses(t, storage, models.Response{UUID: "f", TestID: "t", Method: "GET", Path: "/fault", Status: 200, Body: "body", Fault: tt.fault})
+
+			// Broken connection must not be reused or retried
+			client := &http.Client{
+				Timeout:   time.Second,
+				Transport: &http.Transport{DisableKeepAlives: true},
+			}
+			req, err := http.NewRequest(http.MethodGet, server.URL+"/fault", nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			req.Header.Set(DefaultTestIDHeader, "t")
+
+			resp, err := client.Do(req)
+			if tt.status == 0 {
+				if err == nil {
+					resp.Body.Close()
+					t.Fatalf("status = %d, expected broken connection", resp.StatusCode)
+				}
+			} else {
+				if err != nil {
+					t.Fatal(err)
+				}
+				if resp.StatusCode != tt.status {
+					t.Errorf("status = %d, expected %d", resp.StatusCode, tt.status)
+				}
+				if tt.fault == models.FaultTruncatedBody {
+					_, err = io.ReadAll(resp.Body)
+					if err != io.ErrUnexpectedEOF {
+						t.Errorf("read body error = %v, expected %v", err, io.ErrUnexpectedEOF)
+					}
+				}
+				resp.Body.Close()
+			}
+
+			// Response is written to captured request after connection is closed
+			deadline := time.Now().Add(time.Second)
+			for {
+				requests := getRequests(t, server, "t")
+				if len(requests) == 1 && requests[0].ResponseFault == tt.fault {
+					break
+				}
+				if time.Now().After(deadline) {
+					t.Fatalf("requests = %+v, expected request with fault %s", requests, tt.fault)
+				}
+				time.Sleep(10 * time.Millisecond)
+			}
+		})
+	}
+}
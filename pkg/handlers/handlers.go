@@ -6,6 +6,9 @@ import (
 	"io"
 	"log/slog"
 	"net/http"
+	"net/url"
+	"strings"
+	"time"
 
 	"github.com/onrik/supermock/pkg/models"
 
@@ -13,12 +16,14 @@ import (
 )
 
 type DB interface {
-	Requests(ctx context.Context, testID string) ([]models.Request, error)
-	Response(ctx context.Context, method, path string) (*models.Response, error)
+	Requests(ctx context.Context, filter models.RequestFilter) ([]models.Request, error)
+	Response(ctx context.Context, request models.Request) (*models.Response, error)
 	Responses(ctx context.Context) ([]models.Response, error)
 	ResponseDelete(ctx context.Context, uuid string) error
 	ResponseSave(ctx context.Context, response models.Response) error
-	SaveRequest(ctx context.Context, request models.Request) error
+	SaveRequest(ctx context.Context, request models.Request) (int64, error)
+	UpdateRequestResponse(ctx context.Context, request models.Request) error
+	UnmatchedClean(ctx context.Context) error
 	Clean(ctx context.Context, testID string) error
 }
 
@@ -27,15 +32,42 @@ type SMTP interface {
 	Purge()
 }
 
+const (
+	DefaultTestIDHeader    = "X-Supermock-Test-ID"
+	DefaultTestIDQuery     = "supermock_test_id"
+	DefaultUnmatchedStatus = http.StatusNotImplemented
+)
+
+type Config struct {
+	// TestIDHeader is request header with test id to route request to test responses
+	TestIDHeader string
+	// TestIDQuery is query param used when TestIDHeader is not set
+	TestIDQuery string
+	// UnmatchedStatus and UnmatchedBody are returned for request without matching response.
+	// Description of closest responses is returned if UnmatchedBody is empty.
+	UnmatchedStatus int
+	UnmatchedBody   string
+}
+
 type Handlers struct {
-	db   DB
-	smtp SMTP
+	db       DB
+	smtp     SMTP
+	config   Config
+	random   *random
+	notifier *notifier
 }
 
-func New(db DB, smtp SMTP) *Handlers {
+func New(db DB, smtp SMTP, config Config) *Handlers {
+	if config.UnmatchedStatus == 0 {
+		config.UnmatchedStatus = DefaultUnmatchedStatus
+	}
+
 	return &Handlers{
-		db:   db,
-		smtp: smtp,
+		db:       db,
+		smtp:     smtp,
+		config:   config,
+		random:   newRandom(),
+		notifier: newNotifier(),
 	}
 }
 
@@ -43,20 +75,90 @@ func New(db DB, smtp SMTP) *Handlers {
 Requests
 @openapi GET /_requests/{test_id}
 @openapiParam test_id in=path, type=string, example=194a0bde-d70f-4b16-a303-1ffa2a77c143
+@openapiParam method in=query, type=string, example=POST
+@openapiParam path_prefix in=query, type=string, example=/users/
+@openapiParam header in=query, type=string, example=Bearer
+@openapiParam body in=query, type=string, example=john@example.com
+@openapiParam from in=query, type=string, example=2024-01-01T00:00:00Z
+@openapiParam to in=query, type=string, example=2024-01-02T00:00:00Z
+@openapiParam cursor in=query, type=integer, example=100
+@openapiParam limit in=query, type=integer, example=50
+@openapiParam offset in=query, type=integer, example=0
+@openapiParam sort in=query, type=string, example=desc
 @openapiSummary Get requests for test
-@openapiResponse 200 application/json {"requests": []models.Request}
+@openapiResponse 200 application/json {"requests": []models.Request, "next_cursor": 150}
+@openapiResponse 400 application/json {"message": "sort=oneof=asc desc"}
 */
 func (h *Handlers) Requests(c echo.Context) error {
-	testID := c.Param("test_id")
-	requests, err := h.db.Requests(c.Request().Context(), testID)
+	return h.findRequests(c, false)
+}
+
+/*
+Unmatched
+@openapi GET /_unmatched
+@openapiParam test_id in=query, type=string, example=194a0bde-d70f-4b16-a303-1ffa2a77c143
+@openapiParam method in=query, type=string, example=POST
+@openapiParam path_prefix in=query, type=string, example=/users/
+@openapiParam header in=query, type=string, example=Bearer
+@openapiParam body in=query, type=string, example=john@example.com
+@openapiParam from in=query, type=string, example=2024-01-01T00:00:00Z
+@openapiParam to in=query, type=string, example=2024-01-02T00:00:00Z
+@openapiParam cursor in=query, type=integer, example=100
+@openapiParam limit in=query, type=integer, example=50
+@openapiParam offset in=query, type=integer, example=0
+@openapiParam sort in=query, type=string, example=desc
+@openapiSummary Get requests without matching response
+@openapiResponse 200 application/json {"requests": []models.Request, "next_cursor": 150}
+@openapiResponse 400 application/json {"message": "sort=oneof=asc desc"}
+*/
+func (h *Handlers) Unmatched(c echo.Context) error {
+	return h.findRequests(c, true)
+}
+
+// findRequests returns requests by filter from query.
+// next_cursor is set if the page is full and more requests may follow.
+func (h *Handlers) findRequests(c echo.Context, unmatched bool) error {
+	filter := models.RequestFilter{}
+	err := c.Bind(&filter)
 	if err != nil {
-		slog.Error("Get requests error", "error", err, "test_id", testID)
+		return echo.NewHTTPError(http.StatusBadRequest, err)
+	}
+	err = c.Validate(&filter)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err)
+	}
+	filter.Unmatched = unmatched
+
+	requests, err := h.db.Requests(c.Request().Context(), filter)
+	if err != nil {
+		slog.Error("Get requests error", "error", err, "test_id", filter.TestID)
 		return echo.NewHTTPError(http.StatusInternalServerError, err)
 	}
 
-	return c.JSON(http.StatusOK, echo.Map{
+	result := echo.Map{
 		"requests": requests,
-	})
+	}
+	if filter.Limit > 0 && len(requests) == filter.Limit {
+		result["next_cursor"] = requests[len(requests)-1].Sequence
+	}
+
+	return c.JSON(http.StatusOK, result)
+}
+
+/*
+UnmatchedClean
+@openapi DELETE /_unmatched
+@openapiSummary Delete all requests without matching response
+@openapiResponse 200 application/json {}
+*/
+func (h *Handlers) UnmatchedClean(c echo.Context) error {
+	err := h.db.UnmatchedClean(c.Request().Context())
+	if err != nil {
+		slog.Error("Clean unmatched requests error", "error", err)
+		return echo.NewHTTPError(http.StatusInternalServerError, err)
+	}
+
+	return c.JSON(http.StatusOK, echo.Map{})
 }
 
 /*
@@ -64,7 +166,7 @@ ResponseCreate save response
 @openapi POST /_responses
 @openapiSummary Put response
 @openapiRequest application/json models.Response
-@openapiResponse 400 application/json {"message": "uuid=required,test_id=required,method=required,path=required,status=required"}
+@openapiResponse 400 application/json {"message": "uuid=required,test_id=required,method=required,path=required_without=PathRegex,status=required_without_all=Sequence Fault Alternatives"}
 @openapiResponse 200 application/json {}
 */
 func (h *Handlers) ResponseCreate(c echo.Context) error {
@@ -77,6 +179,10 @@ func (h *Handlers) ResponseCreate(c echo.Context) error {
 	if err != nil {
 		return echo.NewHTTPError(http.StatusBadRequest, err)
 	}
+	err = validateTemplates(response)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
 
 	err = h.db.ResponseSave(c.Request().Context(), response)
 	if err != nil {
@@ -90,6 +196,7 @@ func (h *Handlers) ResponseCreate(c echo.Context) error {
 		"test_id", response.TestID,
 		"method", response.Method,
 		"path", response.Path,
+		"path_regex", response.PathRegex,
 		"status", response.Status,
 		"headers", response.Headers,
 	)
@@ -155,56 +262,143 @@ func (h *Handlers) Clean(c echo.Context) error {
 }
 
 func (h *Handlers) Catch(c echo.Context) error {
+	start := time.Now()
 	method := c.Request().Method
 	path := c.Request().URL.Path
 
 	slog.Debug(fmt.Sprintf("Request<- %s %s", method, path))
 
-	response, err := h.db.Response(c.Request().Context(), method, path)
+	defer c.Request().Body.Close()
+
+	body, err := io.ReadAll(c.Request().Body)
 	if err != nil {
-		slog.Error("Get response error", "error", err, "method", method, "path", path)
 		return echo.NewHTTPError(http.StatusInternalServerError, err)
 	}
 
-	defer c.Request().Body.Close()
+	testID, query := h.testID(c.Request())
+	request := models.Request{
+		TestID:    testID,
+		Method:    method,
+		Path:      path,
+		Query:     query,
+		Headers:   models.Headers(c.Request().Header.Clone()),
+		CreatedAt: start.UTC().Format(models.TimeFormat),
+	}
+	setRequestBody(&request, body)
+
+	response, err := h.db.Response(c.Request().Context(), request)
+	if err != nil {
+		slog.Error("Get response error", "error", err, "method", method, "path", path)
+		return echo.NewHTTPError(http.StatusInternalServerError, err)
+	}
 
 	if response == nil {
-		return c.NoContent(http.StatusNotImplemented)
+		request.Unmatched = true
+		request.LatencyMs = time.Since(start).Milliseconds()
+		_, err = h.saveRequest(c, request)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, err)
+		}
+
+		// Near misses load all responses, so with custom body they are computed only for debug log
+		ctx := c.Request().Context()
+		if h.config.UnmatchedBody != "" {
+			slog.Warn("Unmatched request", "method", method, "path", path, "test_id", request.TestID)
+			if slog.Default().Enabled(ctx, slog.LevelDebug) {
+				slog.Debug("Near misses", "method", method, "path", path, "near_misses", nearMissesReport(request, h.nearMisses(ctx, request)))
+			}
+
+			return c.String(h.config.UnmatchedStatus, h.config.UnmatchedBody)
+		}
+
+		report := nearMissesReport(request, h.nearMisses(ctx, request))
+		slog.Warn("Unmatched request", "method", method, "path", path, "test_id", request.TestID, "near_misses", report)
+
+		return c.String(h.config.UnmatchedStatus, report)
 	}
 
-	body, err := io.ReadAll(c.Request().Body)
+	applySequence(response)
+	applyAlternative(response, h.random)
+	err = applyTemplate(response, request)
 	if err != nil {
-		return echo.NewHTTPError(http.StatusInternalServerError, err)
+		// Response is already consumed, so request is saved with the error it was answered with
+		slog.Error("Render response template error", "error", err, "uuid", response.UUID)
+		message := fmt.Sprintf("render response template error: %s", err)
+		if !response.DisableCatch {
+			request.TestID = response.TestID
+			request.ResponseUUID = response.UUID
+			request.ResponseStatus = http.StatusInternalServerError
+			request.ResponseBody = message
+			request.LatencyMs = time.Since(start).Milliseconds()
+			_, _ = h.saveRequest(c, request)
+		}
+
+		return c.String(http.StatusInternalServerError, message)
 	}
 
+	// Request is saved before reply, so it is visible during delay and hanging faults.
+	// Response is written to it after reply, when response can't be changed anymore.
 	if !response.DisableCatch {
-		request := models.Request{
-			TestID:  response.TestID,
-			Method:  c.Request().Method,
-			Path:    c.Request().URL.Path,
-			Query:   c.Request().URL.RawQuery,
-			Body:    string(body),
-			Headers: map[string]string{},
+		request.TestID = response.TestID
+		request.ResponseUUID = response.UUID
+		request.Sequence, err = h.saveRequest(c, request)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, err)
 		}
+	}
 
-		for k := range c.Request().Header {
-			request.Headers[k] = c.Request().Header.Get(k)
+	err = h.reply(c, response)
+
+	if !response.DisableCatch {
+		request.ResponseStatus = response.Status
+		request.ResponseHeaders = response.Headers
+		request.ResponseBody = response.Body
+		request.ResponseBodyBase64 = response.BodyBase64
+		request.ResponseFault = response.Fault
+		request.LatencyMs = time.Since(start).Milliseconds()
+
+		updateErr := h.db.UpdateRequestResponse(context.WithoutCancel(c.Request().Context()), request)
+		if updateErr != nil {
+			slog.Error("Update request response error", "error", updateErr, "uuid", response.UUID)
 		}
+	}
+
+	return err
+}
+
+// reply waits for response delay and writes response or fault.
+func (h *Handlers) reply(c echo.Context, response *models.Response) error {
+	method := c.Request().Method
+	path := c.Request().URL.Path
+
+	err := sleep(c.Request().Context(), responseDelay(response, h.random))
+	if err != nil {
+		slog.Debug("Request canceled during delay", "method", method, "path", path, "error", err)
+		return nil
+	}
 
-		err = h.db.SaveRequest(c.Request().Context(), request)
+	if response.Fault != "" {
+		err = writeFault(c, response)
 		if err != nil {
-			slog.Error("Save request error", "error", err)
-			return echo.NewHTTPError(http.StatusInternalServerError, err)
+			slog.Error("Write fault error", "error", err, "fault", response.Fault)
 		}
 
-		slog.Info("Request saved", "method", method, "path", path, "test_id", request.TestID)
+		slog.Debug(fmt.Sprintf("Fault-> %s %s", method, path), "fault", response.Fault)
+		return nil
+	}
+
+	data, err := responseBody(response)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err)
 	}
 
 	c.Response().Status = int(response.Status)
-	for k, v := range response.Headers {
-		c.Response().Header().Set(k, v)
+	for k, values := range response.Headers {
+		for _, v := range values {
+			c.Response().Header().Add(k, v)
+		}
 	}
-	_, err = c.Response().Write([]byte(response.Body))
+	_, err = c.Response().Write(data)
 	if err != nil {
 		return echo.NewHTTPError(http.StatusInternalServerError, err)
 	}
@@ -213,3 +407,54 @@ func (h *Handlers) Catch(c echo.Context) error {
 
 	return nil
 }
+
+// saveRequest saves request even if client has gone and returns its sequence number.
+func (h *Handlers) saveRequest(c echo.Context, request models.Request) (int64, error) {
+	sequence, err := h.db.SaveRequest(context.WithoutCancel(c.Request().Context()), request)
+	if err != nil {
+		slog.Error("Save request error", "error", err)
+		return 0, err
+	}
+
+	slog.Info("Request saved", "method", request.Method, "path", request.Path, "test_id", request.TestID)
+	h.notifier.Notify(request.TestID)
+
+	return sequence, nil
+}
+
+// testID returns test id from header or query param and query without that param.
+func (h *Handlers) testID(r *http.Request) (string, string) {
+	if h.config.TestIDHeader != "" {
+		testID := r.Header.Get(h.config.TestIDHeader)
+		if testID != "" {
+			return testID, r.URL.RawQuery
+		}
+	}
+
+	if h.config.TestIDQuery == "" {
+		return "", r.URL.RawQuery
+	}
+
+	testID := r.URL.Query().Get(h.config.TestIDQuery)
+	if testID == "" {
+		return "", r.URL.RawQuery
+	}
+
+	return testID, removeQueryParam(r.URL.RawQuery, h.config.TestIDQuery)
+}
+
+// removeQueryParam removes param from raw query keeping order and escaping of other params.
+func removeQueryParam(rawQuery, name string) string {
+	params := strings.Split(rawQuery, "&")
+	kept := params[:0]
+	for _, param := range params {
+		key, _, _ := strings.Cut(param, "=")
+		key, err := url.QueryUnescape(key)
+		if err == nil && key == name {
+			continue
+		}
+		kept = append(kept, param)
+	}
+
+	return strings.Join(kept, "&")
+}
@@ -0,0 +1,176 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/onrik/supermock/pkg/db"
+	"github.com/onrik/supermock/pkg/models"
+)
+
+// noValidator skips validation, tests put valid data only.
+type noValidator struct{}
+
+func (noValidator) Validate(any) error {
+	return nil
+}
+
+// newTestServer starts router with handlers on memory storage.
+func newTestServer(t *testing.T, config Config) (*httptest.Server, *db.Memory) {
+	t.Helper()
+
+	storage := db.NewMemory()
+	h := New(storage, nil, config)
+
+	e := echo.New()
+	e.Validator = noValidator{}
+	e.POST("/_responses", h.ResponseCreate)
+	e.GET("/_requests/:test_id", h.Requests)
+	e.GET("/_requests/:test_id/wait", h.Wait)
+	e.GET("/_unmatched", h.Unmatched)
+	e.POST("/_chaos/seed", h.ChaosSeed)
+	e.POST("/_verify", h.Verify)
+	e.POST("/_verify/order", h.VerifyOrder)
+	e.Any("/*", h.Catch)
+
+	server := httptest.NewServer(e)
+	t.Cleanup(server.Close)
+
+	return server, storage
+}
+
+// putResponses saves responses to storage.
+func putResponses(t *testing.T, storage *db.Memory, responses ...models.Response) {
+	t.Helper()
+
+	err := storage.ResponsesSave(context.Background(), responses...)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+// do sends request with test id header if testID is not empty and returns status and body.
+// Not empty body is sent as JSON.
+func do(t *testing.T, method, url, testID, body string) (int, string) {
+	t.Helper()
+
+	req, err := http.NewRequest(method, url, strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if testID != "" {
+		req.Header.Set(DefaultTestIDHeader, testID)
+	}
+	if body != "" {
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return resp.StatusCode, string(data)
+}
+
+// getRequests returns captured requests of test.
+func getRequests(t *testing.T, server *httptest.Server, testID string) []models.Request {
+	t.Helper()
+
+	status, body := do(t, http.MethodGet, server.URL+"/_requests/"+testID, "", "")
+	if status != http.StatusOK {
+		t.Fatalf("get requests status = %d, body = %s", status, body)
+	}
+
+	result := struct {
+		Requests []models.Request `json:"requests"`
+	}{}
+	err := json.Unmarshal([]byte(body), &result)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return result.Requests
+}
+
+func TestCatchTestID(t *testing.T) {
+	server, storage := newTestServer(t, Config{
+		TestIDHeader: DefaultTestIDHeader,
+		TestIDQuery:  DefaultTestIDQuery,
+	})
+	putResponses(t, storage,
+		models.Response{UUID: "h", TestID: "header", Method: "GET", Path: "/users", Status: 200, IsPermanent: true},
+		models.Response{UUID: "q", TestID: "query", Method: "GET", Path: "/users", Status: 200, IsPermanent: true},
+	)
+
+	tests := []struct {
+		name   string
+		header string
+		query  string
+		testID string
+		saved  string
+	}{
+		{"header", "header", "b=2&a=1", "header", "b=2&a=1"},
+		{"query", "", "b=2&a=1&supermock_test_id=query", "query", "b=2&a=1"},
+		{"query first", "", "supermock_test_id=query&b=%20x&a=1", "query", "b=%20x&a=1"},
+		{"escaped name", "", "b=2&supermock%5Ftest%5Fid=query", "query", "b=2"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status, body := do(t, http.MethodGet, server.URL+"/users?"+tt.query, tt.header, "")
+			if status != http.StatusOK {
+				t.Fatalf("status = %d, body = %s", status, body)
+			}
+
+			requests := getRequests(t, server, tt.testID)
+			if len(requests) == 0 {
+				t.Fatal("request is not captured")
+			}
+			if query := requests[len(requests)-1].Query; query != tt.saved {
+				t.Errorf("query = %q, expected %q", query, tt.saved)
+			}
+		})
+	}
+}
+
+func TestCatchUnmatched(t *testing.T) {
+	server, storage := newTestServer(t, Config{TestIDHeader: DefaultTestIDHeader})
+	putResponses(t, storage, models.Response{UUID: "r", TestID: "t", Method: "POST", Path: "/users", Status: 200, IsPermanent: true})
+
+	status, _ := do(t, http.MethodGet, server.URL+"/users", "t", "")
+	if status != http.StatusNotImplemented {
+		t.Fatalf("status = %d, expected %d", status, http.StatusNotImplemented)
+	}
+	status, _ = do(t, http.MethodPost, server.URL+"/users", "t", "")
+	if status != http.StatusOK {
+		t.Fatalf("status = %d, expected %d", status, http.StatusOK)
+	}
+
+	requests := getRequests(t, server, "t")
+	if len(requests) != 1 || requests[0].Method != "POST" {
+		t.Errorf("requests = %+v, expected answered POST only", requests)
+	}
+
+	status, body := do(t, http.MethodGet, server.URL+"/_unmatched?test_id=t", "", "")
+	if status != http.StatusOK || !strings.Contains(body, `"method":"GET"`) || strings.Contains(body, `"method":"POST"`) {
+		t.Errorf("unmatched = %d %s, expected unmatched GET only", status, body)
+	}
+
+	_, body = do(t, http.MethodPost, server.URL+"/_verify", "", `{"test_id": "t", "pattern": {"method": "GET"}, "times": {"mode": "exactly", "count": 1}}`)
+	if !strings.Contains(body, `"passed":false`) {
+		t.Errorf("verify = %s, unmatched request must not be verified", body)
+	}
+}
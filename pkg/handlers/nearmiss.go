@@ -0,0 +1,53 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/onrik/supermock/pkg/matcher"
+	"github.com/onrik/supermock/pkg/models"
+)
+
+// maxNearMisses is number of closest responses reported for unmatched request.
+const maxNearMisses = 3
+
+// nearMisses returns closest responses for unmatched request.
+func (h *Handlers) nearMisses(ctx context.Context, request models.Request) []matcher.NearMiss {
+	responses, err := h.db.Responses(ctx)
+	if err != nil {
+		slog.Error("Get responses error", "error", err)
+		return nil
+	}
+
+	return matcher.NearMisses(responses, request, maxNearMisses)
+}
+
+// nearMissesReport returns human readable description of near misses.
+func nearMissesReport(request models.Request, misses []matcher.NearMiss) string {
+	b := strings.Builder{}
+	fmt.Fprintf(&b, "No response matched %s %s\n", request.Method, request.Path)
+	if len(misses) == 0 {
+		return b.String()
+	}
+
+	b.WriteString("\nClosest responses:\n")
+	for i, miss := range misses {
+		fmt.Fprintf(&b, "\n%d. %s\n", i+1, describeResponse(miss.Response))
+		for _, diff := range miss.Diffs {
+			fmt.Fprintf(&b, "   - %s\n", diff)
+		}
+	}
+
+	return b.String()
+}
+
+func describeResponse(response models.Response) string {
+	path := response.Path
+	if response.PathRegex != "" {
+		path = "~" + response.PathRegex
+	}
+
+	return fmt.Sprintf("%s %s (uuid %s)", response.Method, path, response.UUID)
+}
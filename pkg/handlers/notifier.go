@@ -0,0 +1,70 @@
+package handlers
+
+import (
+	"sync"
+)
+
+// notifier wakes up waiters of test when request of that test is captured.
+type notifier struct {
+	mu      sync.Mutex
+	waiters map[string]*waiters
+}
+
+// waiters of test share channel closed by Notify.
+type waiters struct {
+	ch    chan struct{}
+	count int
+}
+
+func newNotifier() *notifier {
+	return &notifier{
+		waiters: map[string]*waiters{},
+	}
+}
+
+// Wait returns channel closed on the next Notify of test.
+// Done must be called with the channel when waiter stops waiting.
+func (n *notifier) Wait(testID string) <-chan struct{} {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	w, ok := n.waiters[testID]
+	if !ok {
+		w = &waiters{ch: make(chan struct{})}
+		n.waiters[testID] = w
+	}
+	w.count++
+
+	return w.ch
+}
+
+// Done unsubscribes waiter and forgets test without waiters.
+func (n *notifier) Done(testID string, ch <-chan struct{}) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	w, ok := n.waiters[testID]
+	if !ok || w.ch != ch {
+		// Already notified
+		return
+	}
+
+	w.count--
+	if w.count == 0 {
+		delete(n.waiters, testID)
+	}
+}
+
+// Notify wakes up all current waiters of test.
+func (n *notifier) Notify(testID string) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	w, ok := n.waiters[testID]
+	if !ok {
+		return
+	}
+
+	close(w.ch)
+	delete(n.waiters, testID)
+}
@@ -0,0 +1,31 @@
+package handlers
+
+import (
+	"testing"
+)
+
+func TestNotifier(t *testing.T) {
+	n := newNotifier()
+
+	first := n.Wait("t")
+	second := n.Wait("t")
+	n.Done("t", first)
+	if len(n.waiters) != 1 {
+		t.Fatalf("waiters = %d, expected 1 while test has waiter", len(n.waiters))
+	}
+
+	n.Notify("t")
+	select {
+	case <-second:
+	default:
+		t.Fatal("waiter is not notified")
+	}
+	// Notified waiter is already forgotten
+	n.Done("t", second)
+
+	third := n.Wait("t")
+	n.Done("t", third)
+	if len(n.waiters) != 0 {
+		t.Errorf("waiters = %d, expected 0 after all waiters are done", len(n.waiters))
+	}
+}
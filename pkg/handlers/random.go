@@ -0,0 +1,47 @@
+package handlers
+
+import (
+	"math/rand/v2"
+	"sync"
+)
+
+// random is source of randomness for delays and chaos responses.
+// It can be seeded through admin API to make runs reproducible.
+type random struct {
+	mu   sync.Mutex
+	rand *rand.Rand
+}
+
+func newRandom() *random {
+	return &random{
+		rand: rand.New(rand.NewPCG(rand.Uint64(), rand.Uint64())),
+	}
+}
+
+func (r *random) Seed(seed uint64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.rand = rand.New(rand.NewPCG(seed, seed))
+}
+
+func (r *random) Float64() float64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.rand.Float64()
+}
+
+func (r *random) NormFloat64() float64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.rand.NormFloat64()
+}
+
+func (r *random) UintN(n uint) uint {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.rand.UintN(n)
+}
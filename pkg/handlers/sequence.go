@@ -0,0 +1,36 @@
+package handlers
+
+import (
+	"github.com/onrik/supermock/pkg/models"
+)
+
+// applySequence replaces status, headers and body of response with current sequence variant.
+// Response hits must already include current request.
+func applySequence(response *models.Response) {
+	n := len(response.Sequence)
+	if n == 0 || response.Hits == 0 {
+		return
+	}
+
+	i := int(response.Hits) - 1
+	if response.SequenceEnd == models.SequenceEndCycle {
+		i %= n
+	} else if i >= n {
+		i = n - 1
+	}
+
+	applyVariant(response, response.Sequence[i])
+}
+
+// applyVariant replaces response reply with variant.
+func applyVariant(response *models.Response, variant models.ResponseVariant) {
+	response.Status = variant.Status
+	response.Headers = variant.Headers
+	response.Body = variant.Body
+	response.BodyBase64 = variant.BodyBase64
+	response.Fault = variant.Fault
+	if variant.DelayMs > 0 {
+		response.DelayMs = variant.DelayMs
+		response.DelayDistribution = models.DelayFixed
+	}
+}
@@ -0,0 +1,60 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/onrik/supermock/pkg/models"
+)
+
+func TestCatchSequence(t *testing.T) {
+	sequence := []models.ResponseVariant{
+		{Status: 500, Body: "first"},
+		{Status: 503, Body: "second"},
+		{Status: 200, Body: "third"},
+	}
+
+	tests := []struct {
+		name      string
+		permanent bool
+		end       string
+		statuses  []int
+	}{
+		{"once", false, "", []int{500, 503, 200, 501}},
+		{"repeat last", true, models.SequenceEndRepeatLast, []int{500, 503, 200, 200, 200}},
+		{"default end", true, "", []int{500, 503, 200, 200}},
+		{"cycle", true, models.SequenceEndCycle, []int{500, 503, 200, 500, 503}},
+		{"not implemented", true, models.SequenceEndNotImplemented, []int{500, 503, 200, 501, 501}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, storage := newTestServer(t, Config{TestIDHeader: DefaultTestIDHeader})
+			putResponses(t, storage, models.Response{
+				UUID:        "s",
+				TestID:      "t",
+				Method:      "GET",
+				Path:        "/status",
+				IsPermanent: tt.permanent,
+				Sequence:    sequence,
+				SequenceEnd: tt.end,
+			})
+
+			statuses := []int{}
+			for range tt.statuses {
+				status, _ := do(t, http.MethodGet, server.URL+"/status", "t", "")
+				statuses = append(statuses, status)
+			}
+			if fmt.Sprint(statuses) != fmt.Sprint(tt.statuses) {
+				t.Errorf("statuses = %v, expected %v", statuses, tt.statuses)
+			}
+
+			// Captured response is the variant that was sent
+			requests := getRequests(t, server, "t")
+			if len(requests) < 2 || requests[1].ResponseStatus != 503 || requests[1].ResponseBody != "second" {
+				t.Errorf("requests = %+v, expected response 503 second", requests)
+			}
+		})
+	}
+}
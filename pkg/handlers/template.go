@@ -0,0 +1,183 @@
+package handlers
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/url"
+	"text/template"
+	"time"
+
+	"github.com/onrik/supermock/pkg/matcher"
+	"github.com/onrik/supermock/pkg/models"
+)
+
+// templateData is available in response templates
+type templateData struct {
+	Method     string
+	Path       string
+	PathParams map[string]string
+	Query      url.Values
+	Headers    http.Header
+	Body       string
+	// JSON is decoded request body, empty object if body is not JSON
+	JSON any
+}
+
+func newTemplateData(response *models.Response, request models.Request) templateData {
+	query, _ := url.ParseQuery(request.Query)
+	data := templateData{
+		Method:     request.Method,
+		Path:       request.Path,
+		PathParams: matcher.PathParams(*response, request.Path),
+		Query:      query,
+		Headers:    http.Header(request.Headers),
+		Body:       request.Body,
+	}
+
+	err := json.Unmarshal([]byte(request.Body), &data.JSON)
+	if err != nil || data.JSON == nil {
+		data.JSON = map[string]any{}
+	}
+
+	return data
+}
+
+func templateFuncs(data templateData) template.FuncMap {
+	return template.FuncMap{
+		"uuid": newUUID,
+		// now returns current UTC time, e.g. {{now.Format "2006-01-02"}}
+		"now": func() time.Time {
+			return time.Now().UTC()
+		},
+		// randInt returns random number in [from, to)
+		"randInt": func(from, to int64) (int64, error) {
+			if to <= from {
+				return 0, fmt.Errorf("randInt: %d must be greater than %d", to, from)
+			}
+			n, err := rand.Int(rand.Reader, big.NewInt(to-from))
+			if err != nil {
+				return 0, err
+			}
+			return from + n.Int64(), nil
+		},
+		"base64": func(s string) string {
+			return base64.StdEncoding.EncodeToString([]byte(s))
+		},
+		"base64Decode": func(s string) (string, error) {
+			b, err := base64.StdEncoding.DecodeString(s)
+			return string(b), err
+		},
+		// jsonPath returns value from request JSON body, empty string if there is no value
+		"jsonPath": func(expr string) any {
+			value, ok := matcher.JSONPath(data.JSON, expr)
+			if !ok || value == nil {
+				return ""
+			}
+			return value
+		},
+		// toJSON encodes value as JSON
+		"toJSON": func(v any) (string, error) {
+			b, err := json.Marshal(v)
+			return string(b), err
+		},
+	}
+}
+
+func parseTemplate(text string, funcs template.FuncMap) (*template.Template, error) {
+	return template.New("").Funcs(funcs).Option("missingkey=zero").Parse(text)
+}
+
+func renderTemplate(text string, data templateData) (string, error) {
+	t, err := parseTemplate(text, templateFuncs(data))
+	if err != nil {
+		return "", err
+	}
+
+	buf := bytes.Buffer{}
+	err = t.Execute(&buf, data)
+	if err != nil {
+		return "", err
+	}
+
+	return buf.String(), nil
+}
+
+// applyTemplate renders body and header values of response with request data.
+func applyTemplate(response *models.Response, request models.Request) error {
+	if !response.Template {
+		return nil
+	}
+
+	data := newTemplateData(response, request)
+
+	body, err := renderTemplate(response.Body, data)
+	if err != nil {
+		return fmt.Errorf("render body error: %w", err)
+	}
+	response.Body = body
+
+	headers := make(models.Headers, len(response.Headers))
+	for k, values := range response.Headers {
+		headers[k] = make([]string, len(values))
+		for i, v := range values {
+			headers[k][i], err = renderTemplate(v, data)
+			if err != nil {
+				return fmt.Errorf("render header %s error: %w", k, err)
+			}
+		}
+	}
+	response.Headers = headers
+
+	return nil
+}
+
+// validateTemplates checks syntax of response templates.
+func validateTemplates(response models.Response) error {
+	if !response.Template {
+		return nil
+	}
+
+	funcs := templateFuncs(templateData{})
+	texts := []string{response.Body}
+	for _, values := range response.Headers {
+		texts = append(texts, values...)
+	}
+	variants := response.Sequence
+	for _, alternative := range response.Alternatives {
+		variants = append(variants, alternative.ResponseVariant)
+	}
+	for _, variant := range variants {
+		texts = append(texts, variant.Body)
+		for _, values := range variant.Headers {
+			texts = append(texts, values...)
+		}
+	}
+
+	for _, text := range texts {
+		_, err := parseTemplate(text, funcs)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// newUUID returns random UUID v4
+func newUUID() (string, error) {
+	b := make([]byte, 16)
+	_, err := rand.Read(b)
+	if err != nil {
+		return "", err
+	}
+
+	b[6] = (b[6] & 0x0f) | 0x40
+	b[8] = (b[8] & 0x3f) | 0x80
+
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:]), nil
+}
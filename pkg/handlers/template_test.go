@@ -0,0 +1,80 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/onrik/supermock/pkg/models"
+)
+
+func TestCatchTemplate(t *testing.T) {
+	tests := []struct {
+		name   string
+		path   string
+		body   string
+		tmpl   string
+		status int
+		result string
+	}{
+		{"path params", "/users/7", "", `id={{.PathParams.id}}`, 200, "id=7"},
+		{"query", "/users/7?page=2", "", `page={{.Query.Get "page"}}`, 200, "page=2"},
+		{"json", "/users/7", `{"name": "john"}`, `name={{.JSON.name}}`, 200, "name=john"},
+		{"json path", "/users/7", `{"user": {"name": "john"}}`, `name={{jsonPath "$.user.name"}}`, 200, "name=john"},
+		{"missing json path", "/users/7", `{"a": 1}`, `name={{jsonPath "$.name"}}`, 200, "name="},
+		{"null json path", "/users/7", `{"name": null}`, `name={{jsonPath "$.name"}}`, 200, "name="},
+		{"body is not changed", "/users/7", `{"name": "<no value>x"}`, `name={{.JSON.name}} raw={{.Body}}`, 200, `name=<no value>x raw={"name": "<no value>x"}`},
+		{"empty body", "/users/7", "", `a={{jsonPath "$.a"}}{{with .JSON.a}}{{.}}{{end}}`, 200, "a="},
+		{"not JSON body", "/users/7", "text", `a={{if .JSON.a}}set{{end}}`, 200, "a="},
+		{"error", "/users/7", "", `{{base64Decode "!"}}`, 500, "render response template error: "},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, storage := newTestServer(t, Config{TestIDHeader: DefaultTestIDHeader})
+			putResponses(t, storage, models.Response{
+				UUID:     "r",
+				TestID:   "t",
+				Method:   "POST",
+				Path:     "/users/{id}",
+				Status:   200,
+				Body:     tt.tmpl,
+				Template: true,
+			})
+
+			status, body := do(t, http.MethodPost, server.URL+tt.path, "t", tt.body)
+			if status != tt.status {
+				t.Fatalf("status = %d, expected %d, body = %s", status, tt.status, body)
+			}
+			if tt.status == 200 && body != tt.result || tt.status != 200 && !strings.HasPrefix(body, tt.result) {
+				t.Errorf("body = %q, expected %q", body, tt.result)
+			}
+
+			requests := getRequests(t, server, "t")
+			if len(requests) != 1 {
+				t.Fatalf("captured %d requests, expected 1", len(requests))
+			}
+			if requests[0].ResponseStatus != uint16(tt.status) || requests[0].ResponseBody != body {
+				t.Errorf("captured response %d %q, expected %d %q", requests[0].ResponseStatus, requests[0].ResponseBody, tt.status, body)
+			}
+		})
+	}
+}
+
+func TestCatchTemplateNow(t *testing.T) {
+	server, storage := newTestServer(t, Config{TestIDHeader: DefaultTestIDHeader})
+	putResponses(t, storage, models.Response{
+		UUID:     "r",
+		TestID:   "t",
+		Method:   "GET",
+		Path:     "/now",
+		Status:   200,
+		Body:     `{{now}}`,
+		Template: true,
+	})
+
+	_, body := do(t, http.MethodGet, server.URL+"/now", "t", "")
+	if !strings.HasSuffix(body, " +0000 UTC") {
+		t.Errorf("now = %q, expected UTC time without monotonic clock", body)
+	}
+}
@@ -0,0 +1,95 @@
+package handlers
+
+import (
+	"log/slog"
+	"net/http"
+	"sort"
+
+	"github.com/labstack/echo/v4"
+	"github.com/onrik/supermock/pkg/matcher"
+	"github.com/onrik/supermock/pkg/models"
+)
+
+/*
+Verify checks number of test requests matching pattern
+@openapi POST /_verify
+@openapiSummary Verify number of requests matching pattern
+@openapiRequest application/json models.Verification
+@openapiResponse 400 application/json {"message": "test_id=required,times.mode=required"}
+@openapiResponse 200 application/json models.VerificationResult
+*/
+func (h *Handlers) Verify(c echo.Context) error {
+	verification := models.Verification{}
+	err := c.Bind(&verification)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err)
+	}
+	err = c.Validate(&verification)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err)
+	}
+
+	requests, err := h.db.Requests(c.Request().Context(), models.RequestFilter{TestID: verification.TestID})
+	if err != nil {
+		slog.Error("Get requests error", "error", err, "test_id", verification.TestID)
+		return echo.NewHTTPError(http.StatusInternalServerError, err)
+	}
+
+	result := models.VerificationResult{
+		Matched:    []models.Request{},
+		Mismatched: []models.Request{},
+	}
+	for _, request := range requests {
+		if matcher.MatchPattern(verification.Pattern, request) {
+			result.Matched = append(result.Matched, request)
+		} else {
+			result.Mismatched = append(result.Mismatched, request)
+		}
+	}
+	result.Count = len(result.Matched)
+	result.Passed = matcher.MatchTimes(verification.Times, result.Count)
+
+	return c.JSON(http.StatusOK, result)
+}
+
+/*
+VerifyOrder checks test requests matching patterns occurred in order
+@openapi POST /_verify/order
+@openapiSummary Verify order of requests matching patterns
+@openapiRequest application/json models.OrderVerification
+@openapiResponse 400 application/json {"message": "test_id=required,patterns=required"}
+@openapiResponse 200 application/json models.OrderVerificationResult
+*/
+func (h *Handlers) VerifyOrder(c echo.Context) error {
+	verification := models.OrderVerification{}
+	err := c.Bind(&verification)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err)
+	}
+	err = c.Validate(&verification)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err)
+	}
+
+	requests, err := h.db.Requests(c.Request().Context(), models.RequestFilter{TestID: verification.TestID})
+	if err != nil {
+		slog.Error("Get requests error", "error", err, "test_id", verification.TestID)
+		return echo.NewHTTPError(http.StatusInternalServerError, err)
+	}
+
+	// Requests are saved after response is selected, so saving order may differ from receiving order
+	sort.SliceStable(requests, func(i, j int) bool {
+		if requests[i].CreatedAt != requests[j].CreatedAt {
+			return requests[i].CreatedAt < requests[j].CreatedAt
+		}
+		return requests[i].Sequence < requests[j].Sequence
+	})
+
+	matched := matcher.MatchOrder(verification.Patterns, requests)
+
+	return c.JSON(http.StatusOK, models.OrderVerificationResult{
+		Passed:   len(matched) == len(verification.Patterns),
+		Matched:  matched,
+		Requests: requests,
+	})
+}
@@ -0,0 +1,114 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/onrik/supermock/pkg/models"
+)
+
+func TestVerify(t *testing.T) {
+	server, storage := newTestServer(t, Config{TestIDHeader: DefaultTestIDHeader})
+	putResponses(t, storage, models.Response{UUID: "u", TestID: "t", Method: "ANY", PathRegex: "^/users", Status: 200, IsPermanent: true})
+
+	do(t, http.MethodPost, server.URL+"/users", "t", `{"name": "Bob"}`)
+	do(t, http.MethodPost, server.URL+"/users", "t", `{"name": "Alice"}`)
+	do(t, http.MethodGet, server.URL+"/users/1", "t", "")
+
+	tests := []struct {
+		name         string
+		verification string
+		passed       bool
+		count        int
+	}{
+		{"exactly", `{"test_id": "t", "pattern": {"method": "POST", "path": "/users"}, "times": {"mode": "exactly", "count": 2}}`, true, 2},
+		{"exactly fails", `{"test_id": "t", "pattern": {"method": "POST"}, "times": {"mode": "exactly", "count": 1}}`, false, 2},
+		{"at least", `{"test_id": "t", "pattern": {"path_regex": "^/users"}, "times": {"mode": "at_least", "count": 3}}`, true, 3},
+		{"never", `{"test_id": "t", "pattern": {"method": "DELETE"}, "times": {"mode": "never"}}`, true, 0},
+		{"never fails", `{"test_id": "t", "pattern": {"method": "GET"}, "times": {"mode": "never"}}`, false, 1},
+		{"body", `{"test_id": "t", "pattern": {"match_body": {"contains_json": {"name": "Bob"}}}, "times": {"mode": "exactly", "count": 1}}`, true, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status, body := do(t, http.MethodPost, server.URL+"/_verify", "", tt.verification)
+			if status != http.StatusOK {
+				t.Fatalf("verify = %d %s", status, body)
+			}
+
+			result := models.VerificationResult{}
+			err := json.Unmarshal([]byte(body), &result)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if result.Passed != tt.passed || result.Count != tt.count {
+				t.Errorf("passed = %v, count = %d, expected %v, %d", result.Passed, result.Count, tt.passed, tt.count)
+			}
+			if len(result.Matched)+len(result.Mismatched) != 3 {
+				t.Errorf("matched = %d, mismatched = %d, expected 3 requests", len(result.Matched), len(result.Mismatched))
+			}
+		})
+	}
+}
+
+func TestVerifyOrder(t *testing.T) {
+	server, storage := newTestServer(t, Config{TestIDHeader: DefaultTestIDHeader})
+	putResponses(t, storage, models.Response{UUID: "u", TestID: "t", Method: "ANY", PathRegex: "^/", Status: 200, IsPermanent: true})
+
+	do(t, http.MethodGet, server.URL+"/b", "t", "")
+	do(t, http.MethodGet, server.URL+"/c", "t", "")
+	// Request received first but saved last
+	_, err := storage.SaveRequest(context.Background(), models.Request{
+		TestID:    "t",
+		Method:    "GET",
+		Path:      "/a",
+		Headers:   models.Headers{},
+		CreatedAt: time.Now().Add(-time.Hour).UTC().Format(models.TimeFormat),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		patterns string
+		passed   bool
+		matched  int
+	}{
+		{"in order", `[{"path": "/a"}, {"path": "/c"}]`, true, 2},
+		{"all", `[{"path": "/a"}, {"path": "/b"}, {"path": "/c"}]`, true, 3},
+		{"wrong order", `[{"path": "/c"}, {"path": "/a"}]`, false, 1},
+		{"saving order", `[{"path": "/b"}, {"path": "/a"}]`, false, 1},
+		{"missing", `[{"path": "/a"}, {"path": "/d"}]`, false, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status, body := do(t, http.MethodPost, server.URL+"/_verify/order", "", `{"test_id": "t", "patterns": `+tt.patterns+`}`)
+			if status != http.StatusOK {
+				t.Fatalf("verify order = %d %s", status, body)
+			}
+
+			result := models.OrderVerificationResult{}
+			err := json.Unmarshal([]byte(body), &result)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if result.Passed != tt.passed || len(result.Matched) != tt.matched {
+				t.Errorf("passed = %v, matched = %d, expected %v, %d", result.Passed, len(result.Matched), tt.passed, tt.matched)
+			}
+
+			paths := []string{}
+			for _, request := range result.Requests {
+				paths = append(paths, request.Path)
+			}
+			if fmt.Sprint(paths) != "[/a /b /c]" {
+				t.Errorf("requests = %v, expected in receiving order", paths)
+			}
+		})
+	}
+}
@@ -0,0 +1,96 @@
+package handlers
+
+import (
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/onrik/supermock/pkg/matcher"
+	"github.com/onrik/supermock/pkg/models"
+)
+
+// DefaultWaitTimeout is used by Wait if timeout is not given.
+const DefaultWaitTimeout = 5 * time.Second
+
+/*
+Wait blocks until count of test requests matching method and path are captured
+@openapi GET /_requests/{test_id}/wait
+@openapiParam test_id in=path, type=string, example=194a0bde-d70f-4b16-a303-1ffa2a77c143
+@openapiParam count in=query, type=integer, example=1
+@openapiParam timeout in=query, type=string, example=5s
+@openapiParam method in=query, type=string, example=POST
+@openapiParam path in=query, type=string, example=/users/{id}
+@openapiSummary Wait for requests of test
+@openapiResponse 200 application/json {"requests": []models.Request}
+@openapiResponse 400 application/json {"message": "invalid timeout"}
+@openapiResponse 408 application/json {"requests": []models.Request}
+*/
+func (h *Handlers) Wait(c echo.Context) error {
+	testID := c.Param("test_id")
+	pattern := models.RequestPattern{
+		Method: c.QueryParam("method"),
+		Path:   c.QueryParam("path"),
+	}
+
+	count := 1
+	if v := c.QueryParam("count"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			return echo.NewHTTPError(http.StatusBadRequest, "invalid count")
+		}
+		count = n
+	}
+
+	timeout := DefaultWaitTimeout
+	if v := c.QueryParam("timeout"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil || d < 0 {
+			return echo.NewHTTPError(http.StatusBadRequest, "invalid timeout")
+		}
+		timeout = d
+	}
+
+	ctx := c.Request().Context()
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	for {
+		// Subscribe before reading, so request captured in between is not missed
+		captured := h.notifier.Wait(testID)
+
+		requests, err := h.db.Requests(ctx, models.RequestFilter{TestID: testID})
+		if err != nil {
+			h.notifier.Done(testID, captured)
+			slog.Error("Get requests error", "error", err, "test_id", testID)
+			return echo.NewHTTPError(http.StatusInternalServerError, err)
+		}
+
+		matched := []models.Request{}
+		for _, request := range requests {
+			if matcher.MatchPattern(pattern, request) {
+				matched = append(matched, request)
+			}
+		}
+
+		if len(matched) >= count {
+			h.notifier.Done(testID, captured)
+			return c.JSON(http.StatusOK, echo.Map{
+				"requests": matched,
+			})
+		}
+
+		select {
+		case <-captured:
+		case <-timer.C:
+			h.notifier.Done(testID, captured)
+			return c.JSON(http.StatusRequestTimeout, echo.Map{
+				"requests": matched,
+			})
+		case <-ctx.Done():
+			h.notifier.Done(testID, captured)
+			return ctx.Err()
+		}
+	}
+}
@@ -0,0 +1,71 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/onrik/supermock/pkg/models"
+)
+
+func TestWait(t *testing.T) {
+	server, storage := newTestServer(t, Config{TestIDHeader: DefaultTestIDHeader})
+	putResponses(t, storage, models.Response{UUID: "u", TestID: "t", Method: "ANY", Path: "/users", Status: 200, IsPermanent: true})
+
+	do(t, http.MethodGet, server.URL+"/users", "t", "")
+
+	// Requests are captured while waiter is blocked
+	go func() {
+		for range 2 {
+			time.Sleep(50 * time.Millisecond)
+			req, _ := http.NewRequest(http.MethodPost, server.URL+"/users", nil)
+			req.Header.Set(DefaultTestIDHeader, "t")
+			resp, err := http.DefaultClient.Do(req)
+			if err == nil {
+				resp.Body.Close()
+			}
+		}
+	}()
+
+	tests := []struct {
+		name   string
+		query  string
+		status int
+		count  int
+	}{
+		{"captured", "?method=GET", http.StatusOK, 1},
+		{"woken by capture", "?method=POST&count=2&timeout=2s", http.StatusOK, 2},
+		{"timeout", "?method=DELETE&timeout=50ms", http.StatusRequestTimeout, 0},
+		{"timeout with matched", "?count=5&timeout=50ms", http.StatusRequestTimeout, 3},
+		{"invalid count", "?count=0", http.StatusBadRequest, 0},
+		{"invalid timeout", "?timeout=soon", http.StatusBadRequest, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			start := time.Now()
+			status, body := do(t, http.MethodGet, server.URL+"/_requests/t/wait"+tt.query, "", "")
+			if status != tt.status {
+				t.Fatalf("wait = %d %s, expected %d", status, body, tt.status)
+			}
+			if time.Since(start) > time.Second {
+				t.Errorf("wait took %s, expected to return on capture", time.Since(start))
+			}
+			if status == http.StatusBadRequest {
+				return
+			}
+
+			result := struct {
+				Requests []models.Request `json:"requests"`
+			}{}
+			err := json.Unmarshal([]byte(body), &result)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(result.Requests) != tt.count {
+				t.Errorf("requests = %d, expected %d", len(result.Requests), tt.count)
+			}
+		})
+	}
+}
@@ -0,0 +1,95 @@
+package matcher
+
+import (
+	"encoding/json"
+	"reflect"
+
+	"github.com/onrik/supermock/pkg/models"
+)
+
+// MatchBody reports whether request body satisfies m.
+// Nil matcher matches any body.
+func MatchBody(m *models.BodyMatcher, body string) bool {
+	if m == nil {
+		return true
+	}
+
+	var doc any
+	err := json.Unmarshal([]byte(body), &doc)
+	if err != nil {
+		return false
+	}
+
+	if m.EqualJSON != nil && !reflect.DeepEqual(normalizeJSON(m.EqualJSON), doc) {
+		return false
+	}
+
+	if m.ContainsJSON != nil && !containsJSON(doc, normalizeJSON(m.ContainsJSON)) {
+		return false
+	}
+
+	for expr, expected := range m.JSONPath {
+		value, ok := JSONPath(doc, expr)
+		if !ok || !reflect.DeepEqual(normalizeJSON(expected), value) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// normalizeJSON converts value to the form produced by json.Unmarshal into any,
+// so values built in code (ints, structs) compare with decoded body.
+func normalizeJSON(v any) any {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return v
+	}
+
+	var normalized any
+	err = json.Unmarshal(data, &normalized)
+	if err != nil {
+		return v
+	}
+
+	return normalized
+}
+
+// containsJSON reports whether actual contains all fields of expected.
+// Every element of expected array must be contained by some element of actual array.
+func containsJSON(actual, expected any) bool {
+	switch e := expected.(type) {
+	case map[string]any:
+		a, ok := actual.(map[string]any)
+		if !ok {
+			return false
+		}
+		for k, v := range e {
+			item, ok := a[k]
+			if !ok || !containsJSON(item, v) {
+				return false
+			}
+		}
+		return true
+	case []any:
+		a, ok := actual.([]any)
+		if !ok {
+			return false
+		}
+		for _, v := range e {
+			found := false
+			for _, item := range a {
+				if containsJSON(item, v) {
+					found = true
+					break
+				}
+			}
+			if !found {
+				return false
+			}
+		}
+		return true
+	default:
+		return reflect.DeepEqual(actual, expected)
+	}
+}
@@ -0,0 +1,47 @@
+package matcher
+
+import (
+	"testing"
+
+	"github.com/onrik/supermock/pkg/models"
+)
+
+func TestMatchBody(t *testing.T) {
+	body := `{"user": {"name": "john", "age": 30}, "items": [{"id": 1, "tags": ["a", "b"]}, {"id": 2}], "ok": true}`
+
+	tests := []struct {
+		name string
+		m    *models.BodyMatcher
+		body string
+		ok   bool
+	}{
+		{"nil matcher", nil, "not json", true},
+		{"invalid json", &models.BodyMatcher{ContainsJSON: map[string]any{}}, "not json", false},
+		{"equal ignores order and spaces", &models.BodyMatcher{EqualJSON: map[string]any{"b": 2, "a": 1}}, `{ "a": 1, "b": 2 }`, true},
+		{"equal with extra field", &models.BodyMatcher{EqualJSON: map[string]any{"a": 1}}, `{"a": 1, "b": 2}`, false},
+		{"contains nested", &models.BodyMatcher{ContainsJSON: map[string]any{"user": map[string]any{"name": "john"}}}, body, true},
+		{"contains wrong value", &models.BodyMatcher{ContainsJSON: map[string]any{"user": map[string]any{"name": "jane"}}}, body, false},
+		{"contains array element", &models.BodyMatcher{ContainsJSON: map[string]any{"items": []any{map[string]any{"id": 2}}}}, body, true},
+		{"contains array elements in any order", &models.BodyMatcher{ContainsJSON: map[string]any{"items": []any{map[string]any{"id": 2}, map[string]any{"id": 1}}}}, body, true},
+		{"contains nested array", &models.BodyMatcher{ContainsJSON: map[string]any{"items": []any{map[string]any{"tags": []any{"b"}}}}}, body, true},
+		{"contains missing array element", &models.BodyMatcher{ContainsJSON: map[string]any{"items": []any{map[string]any{"id": 3}}}}, body, false},
+		{"contains array for object", &models.BodyMatcher{ContainsJSON: map[string]any{"user": []any{}}}, body, false},
+		{"contains struct", &models.BodyMatcher{ContainsJSON: struct {
+			OK bool `json:"ok"`
+		}{true}}, body, true},
+		{"json path", &models.BodyMatcher{JSONPath: map[string]any{"$.items[1].id": 2, "$.user.name": "john"}}, body, true},
+		{"json path wrong value", &models.BodyMatcher{JSONPath: map[string]any{"$.user.age": 31}}, body, false},
+		{"json path missing", &models.BodyMatcher{JSONPath: map[string]any{"$.user.email": "x"}}, body, false},
+		{"all matchers", &models.BodyMatcher{
+			EqualJSON:    map[string]any{"a": []any{1, 2}},
+			ContainsJSON: map[string]any{"a": []any{2}},
+			JSONPath:     map[string]any{"$.a[0]": 1},
+		}, `{"a": [1, 2]}`, true},
+	}
+
+	for _, tt := range tests {
+		if ok := MatchBody(tt.m, tt.body); ok != tt.ok {
+			t.Errorf("%s: MatchBody = %v, want %v", tt.name, ok, tt.ok)
+		}
+	}
+}
@@ -0,0 +1,262 @@
+package matcher
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"reflect"
+	"sort"
+
+	"github.com/onrik/supermock/pkg/models"
+)
+
+// maxPathDistance is the largest edit distance of paths considered similar.
+const maxPathDistance = 3
+
+// NearMiss is a response which almost matched request.
+type NearMiss struct {
+	Response models.Response
+	// Diffs are human readable reasons why response did not match
+	Diffs []string
+}
+
+// NearMisses returns at most limit responses closest to request, the closest first.
+// Responses with the same path or with similar path and the same method are considered.
+// Request with test id is compared only with responses of that test.
+func NearMisses(responses []models.Response, request models.Request, limit int) []NearMiss {
+	type candidate struct {
+		NearMiss
+		distance int
+	}
+
+	candidates := []candidate{}
+	for _, response := range responses {
+		if request.TestID != "" && response.TestID != request.TestID {
+			continue
+		}
+
+		distance := pathDistance(response, request.Path)
+		methodOK := response.Method == request.Method || response.Method == MethodAny
+		if distance > 0 && (!methodOK || distance > maxPathDistance) {
+			continue
+		}
+
+		diffs := Diff(response, request)
+		if len(diffs) == 0 {
+			continue
+		}
+
+		candidates = append(candidates, candidate{NearMiss{response, diffs}, distance})
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		if len(candidates[i].Diffs) != len(candidates[j].Diffs) {
+			return len(candidates[i].Diffs) < len(candidates[j].Diffs)
+		}
+		return candidates[i].distance < candidates[j].distance
+	})
+
+	misses := []NearMiss{}
+	for i := 0; i < len(candidates) && i < limit; i++ {
+		misses = append(misses, candidates[i].NearMiss)
+	}
+
+	return misses
+}
+
+// Diff returns human readable reasons why response does not match request.
+// Empty result means response matches.
+func Diff(response models.Response, request models.Request) []string {
+	diffs := []string{}
+
+	if request.TestID != "" && response.TestID != request.TestID {
+		diffs = append(diffs, fmt.Sprintf("test id is %q, expected %q", request.TestID, response.TestID))
+	}
+
+	if Exhausted(response) {
+		diffs = append(diffs, "sequence is exhausted")
+	}
+
+	if response.Method != request.Method && response.Method != MethodAny {
+		diffs = append(diffs, fmt.Sprintf("method is %s, expected %s", request.Method, response.Method))
+	}
+
+	if response.PathRegex != "" {
+		if !MatchRegexp(response.PathRegex, request.Path) {
+			diffs = append(diffs, fmt.Sprintf("path %q does not match regex %q", request.Path, response.PathRegex))
+		}
+	} else if _, ok := MatchPath(response.Path, request.Path); !ok {
+		diffs = append(diffs, fmt.Sprintf("path %q does not match %q", request.Path, response.Path))
+	}
+
+	diffs = append(diffs, diffQuery(response.Query, request.Query)...)
+	diffs = append(diffs, diffHeaders(response.MatchHeaders, request.Headers)...)
+	diffs = append(diffs, diffBody(response.MatchBody, request.Body)...)
+
+	return diffs
+}
+
+func diffQuery(m *models.QueryMatcher, rawQuery string) []string {
+	if m == nil {
+		return nil
+	}
+
+	diffs := []string{}
+	values, _ := url.ParseQuery(rawQuery)
+	for _, name := range sortedKeys(m.Params) {
+		v, ok := values[name]
+		if !MatchValue(m.Params[name], v, ok) {
+			diffs = append(diffs, diffValue("query parameter", name, m.Params[name], v, ok))
+		}
+	}
+
+	if m.Exact {
+		for _, name := range sortedKeys(values) {
+			param, ok := m.Params[name]
+			if !ok || param.Absent {
+				diffs = append(diffs, fmt.Sprintf("unexpected query parameter %q", name))
+			}
+		}
+	}
+
+	return diffs
+}
+
+func diffHeaders(matchers map[string]models.ValueMatcher, headers models.Headers) []string {
+	diffs := []string{}
+	for _, name := range sortedKeys(matchers) {
+		values, ok := headers[http.CanonicalHeaderKey(name)]
+		if !MatchValue(matchers[name], values, ok) {
+			diffs = append(diffs, diffValue("header", name, matchers[name], values, ok))
+		}
+	}
+
+	return diffs
+}
+
+func diffBody(m *models.BodyMatcher, body string) []string {
+	if m == nil {
+		return nil
+	}
+
+	var doc any
+	err := json.Unmarshal([]byte(body), &doc)
+	if err != nil {
+		return []string{"body is not valid JSON"}
+	}
+
+	diffs := []string{}
+	if m.EqualJSON != nil && !reflect.DeepEqual(normalizeJSON(m.EqualJSON), doc) {
+		diffs = append(diffs, fmt.Sprintf("body is %s, expected %s", toJSON(doc), toJSON(m.EqualJSON)))
+	}
+
+	if m.ContainsJSON != nil && !containsJSON(doc, normalizeJSON(m.ContainsJSON)) {
+		diffs = append(diffs, fmt.Sprintf("body %s does not contain %s", toJSON(doc), toJSON(m.ContainsJSON)))
+	}
+
+	for _, expr := range sortedKeys(m.JSONPath) {
+		value, ok := JSONPath(doc, expr)
+		if !ok {
+			diffs = append(diffs, fmt.Sprintf("body has no %s, expected %s", expr, toJSON(m.JSONPath[expr])))
+		} else if !reflect.DeepEqual(normalizeJSON(m.JSONPath[expr]), value) {
+			diffs = append(diffs, fmt.Sprintf("body %s is %s, expected %s", expr, toJSON(value), toJSON(m.JSONPath[expr])))
+		}
+	}
+
+	return diffs
+}
+
+func diffValue(kind, name string, m models.ValueMatcher, values []string, present bool) string {
+	var expected string
+	switch {
+	case m.Absent:
+		expected = "absent"
+	case m.Equal != "" && m.Regex != "":
+		expected = fmt.Sprintf("%q matching regex %q", m.Equal, m.Regex)
+	case m.Equal != "":
+		expected = fmt.Sprintf("%q", m.Equal)
+	case m.Regex != "":
+		expected = fmt.Sprintf("matching regex %q", m.Regex)
+	default:
+		expected = "present"
+	}
+
+	if !present {
+		return fmt.Sprintf("%s %q is missing, expected %s", kind, name, expected)
+	}
+
+	return fmt.Sprintf("%s %q is %q, expected %s", kind, name, values, expected)
+}
+
+func toJSON(v any) string {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+
+	return string(data)
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
+
+// pathDistance returns edit distance between response path and request path.
+// Template segments match any request segment, regular expressions are either 0 or too far.
+func pathDistance(response models.Response, path string) int {
+	if response.PathRegex != "" {
+		if MatchRegexp(response.PathRegex, path) {
+			return 0
+		}
+		return maxPathDistance + 1
+	}
+
+	if _, ok := MatchPath(response.Path, path); ok {
+		return 0
+	}
+
+	pattern, segments := splitPath(response.Path), splitPath(path)
+	if len(pattern) != len(segments) {
+		return levenshtein(response.Path, path)
+	}
+
+	distance := 0
+	for i, segment := range pattern {
+		if segment == "*" || segment == "**" || isParam(segment) {
+			continue
+		}
+		distance += levenshtein(segment, segments[i])
+	}
+
+	return distance
+}
+
+func levenshtein(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(ra); i++ {
+		cur := make([]int, len(rb)+1)
+		cur[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev = cur
+	}
+
+	return prev[len(rb)]
+}
@@ -0,0 +1,99 @@
+package matcher
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/onrik/supermock/pkg/models"
+)
+
+func TestDiff(t *testing.T) {
+	request := models.Request{
+		TestID:  "t",
+		Method:  "GET",
+		Path:    "/users/1",
+		Query:   "page=2&debug=1",
+		Headers: models.Headers{"X-Token": {"zzz"}},
+		Body:    `{"a": 2}`,
+	}
+
+	tests := []struct {
+		name     string
+		response models.Response
+		diffs    []string
+	}{
+		{"match", models.Response{TestID: "t", Method: "GET", Path: "/users/{id}"}, []string{}},
+		{"method", models.Response{TestID: "t", Method: "POST", Path: "/users/{id}"}, []string{"method is GET, expected POST"}},
+		{"path", models.Response{TestID: "t", Method: "GET", Path: "/accounts/{id}"}, []string{`path "/users/1" does not match "/accounts/{id}"`}},
+		{"test id", models.Response{TestID: "o", Method: "GET", Path: "/users/1"}, []string{`test id is "t", expected "o"`}},
+		{"query", models.Response{TestID: "t", Method: "GET", Path: "/users/1", Query: &models.QueryMatcher{
+			Params: map[string]models.ValueMatcher{"page": {Equal: "1"}, "sort": {}},
+			Exact:  true,
+		}}, []string{
+			`query parameter "page" is ["2"], expected "1"`,
+			`query parameter "sort" is missing, expected present`,
+			`unexpected query parameter "debug"`,
+		}},
+		{"headers", models.Response{TestID: "t", Method: "GET", Path: "/users/1", MatchHeaders: map[string]models.ValueMatcher{
+			"x-token":  {Regex: "^abc"},
+			"X-Absent": {Absent: true},
+		}}, []string{`header "x-token" is ["zzz"], expected matching regex "^abc"`}},
+		{"body", models.Response{TestID: "t", Method: "GET", Path: "/users/1", MatchBody: &models.BodyMatcher{
+			JSONPath: map[string]any{"$.a": 1, "$.b": true},
+		}}, []string{"body $.a is 2, expected 1", "body has no $.b, expected true"}},
+	}
+
+	for _, tt := range tests {
+		diffs := Diff(tt.response, request)
+		if !reflect.DeepEqual(diffs, tt.diffs) {
+			t.Errorf("%s: Diff = %q, want %q", tt.name, diffs, tt.diffs)
+		}
+	}
+}
+
+func TestNearMisses(t *testing.T) {
+	responses := []models.Response{
+		{UUID: "far", TestID: "t", Method: "GET", Path: "/completely/different"},
+		{UUID: "method", TestID: "t", Method: "POST", Path: "/users/{id}"},
+		{UUID: "similar", TestID: "t", Method: "GET", Path: "/user/{id}"},
+		{UUID: "similar other method", TestID: "t", Method: "PUT", Path: "/user/{id}"},
+		{UUID: "header", TestID: "t", Method: "GET", Path: "/users/{id}", MatchHeaders: map[string]models.ValueMatcher{"X-Token": {}}},
+		{UUID: "other test", TestID: "o", Method: "GET", Path: "/users/{id}"},
+	}
+	request := models.Request{TestID: "t", Method: "GET", Path: "/users/1"}
+
+	misses := NearMisses(responses, request, 10)
+	uuids := []string{}
+	for _, miss := range misses {
+		uuids = append(uuids, miss.Response.UUID)
+	}
+
+	want := []string{"method", "header", "similar"}
+	if !reflect.DeepEqual(uuids, want) {
+		t.Errorf("NearMisses = %q, want %q", uuids, want)
+	}
+
+	if misses := NearMisses(responses, request, 1); len(misses) != 1 {
+		t.Errorf("NearMisses with limit 1 returned %d", len(misses))
+	}
+}
+
+func TestLevenshtein(t *testing.T) {
+	tests := []struct {
+		a, b     string
+		distance int
+	}{
+		{"", "", 0},
+		{"abc", "", 3},
+		{"", "abc", 3},
+		{"users", "user", 1},
+		{"kitten", "sitting", 3},
+		{"héllo", "hello", 1},
+	}
+
+	for _, tt := range tests {
+		if d := levenshtein(tt.a, tt.b); d != tt.distance {
+			t.Errorf("levenshtein(%q, %q) = %d, want %d", tt.a, tt.b, d, tt.distance)
+		}
+	}
+}
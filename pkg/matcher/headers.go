@@ -0,0 +1,20 @@
+package matcher
+
+import (
+	"net/http"
+
+	"github.com/onrik/supermock/pkg/models"
+)
+
+// MatchHeaders reports whether request headers satisfy matchers.
+// Header names are case insensitive.
+func MatchHeaders(matchers map[string]models.ValueMatcher, headers models.Headers) bool {
+	for name, m := range matchers {
+		values, ok := headers[http.CanonicalHeaderKey(name)]
+		if !MatchValue(m, values, ok) {
+			return false
+		}
+	}
+
+	return true
+}
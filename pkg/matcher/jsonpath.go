@@ -0,0 +1,97 @@
+package matcher
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// JSONPath returns value of decoded JSON document at expression.
+// Supported subset: root "$", child ".name" or "['name']" and array index "[0]".
+func JSONPath(doc any, expr string) (any, bool) {
+	steps, err := parseJSONPath(expr)
+	if err != nil {
+		return nil, false
+	}
+
+	value := doc
+	for _, step := range steps {
+		switch v := value.(type) {
+		case map[string]any:
+			if step.index != nil {
+				return nil, false
+			}
+			item, ok := v[step.name]
+			if !ok {
+				return nil, false
+			}
+			value = item
+		case []any:
+			if step.index == nil || *step.index < 0 || *step.index >= len(v) {
+				return nil, false
+			}
+			value = v[*step.index]
+		default:
+			return nil, false
+		}
+	}
+
+	return value, true
+}
+
+type jsonPathStep struct {
+	name  string
+	index *int
+}
+
+// ValidateJSONPath checks expression syntax.
+func ValidateJSONPath(expr string) error {
+	_, err := parseJSONPath(expr)
+	return err
+}
+
+func parseJSONPath(expr string) ([]jsonPathStep, error) {
+	if !strings.HasPrefix(expr, "$") {
+		return nil, fmt.Errorf("jsonpath must start with $: %s", expr)
+	}
+
+	steps := []jsonPathStep{}
+	rest := expr[1:]
+	for rest != "" {
+		switch {
+		case rest[0] == '.':
+			end := strings.IndexAny(rest[1:], ".[")
+			if end < 0 {
+				end = len(rest) - 1
+			}
+			name := rest[1 : end+1]
+			if name == "" {
+				return nil, fmt.Errorf("empty name in jsonpath: %s", expr)
+			}
+			steps = append(steps, jsonPathStep{name: name})
+			rest = rest[end+1:]
+		case strings.HasPrefix(rest, "['"):
+			end := strings.Index(rest, "']")
+			if end < 0 {
+				return nil, fmt.Errorf("unclosed bracket in jsonpath: %s", expr)
+			}
+			steps = append(steps, jsonPathStep{name: rest[2:end]})
+			rest = rest[end+2:]
+		case rest[0] == '[':
+			end := strings.Index(rest, "]")
+			if end < 0 {
+				return nil, fmt.Errorf("unclosed bracket in jsonpath: %s", expr)
+			}
+			index, err := strconv.Atoi(rest[1:end])
+			if err != nil {
+				return nil, fmt.Errorf("invalid index in jsonpath: %s", expr)
+			}
+			steps = append(steps, jsonPathStep{index: &index})
+			rest = rest[end+1:]
+		default:
+			return nil, fmt.Errorf("unexpected %q in jsonpath: %s", rest[0], expr)
+		}
+	}
+
+	return steps, nil
+}
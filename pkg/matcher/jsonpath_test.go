@@ -0,0 +1,72 @@
+package matcher
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestValidateJSONPath(t *testing.T) {
+	tests := map[string]bool{
+		"$":                  true,
+		"$.a":                true,
+		"$.a.b":              true,
+		"$.items[0].id":      true,
+		"$['a b']":           true,
+		"$['a'][1]['c']":     true,
+		"":                   false,
+		"a.b":                false,
+		"$.":                 false,
+		"$..a":               false,
+		"$[":                 false,
+		"$['a'":              false,
+		"$[x]":               false,
+		"$a":                 false,
+		"$.items[0]extra":    false,
+		"$.items[-1]":        true,
+		"$.a['b.c'].d":       true,
+		"$.items[0][1][2].x": true,
+	}
+
+	for expr, valid := range tests {
+		err := ValidateJSONPath(expr)
+		if (err == nil) != valid {
+			t.Errorf("ValidateJSONPath(%q) = %v, want valid %v", expr, err, valid)
+		}
+	}
+}
+
+func TestJSONPath(t *testing.T) {
+	var doc any
+	err := json.Unmarshal([]byte(`{"a": {"b": 1}, "items": [{"id": "x"}, {"id": "y"}], "a b": true, "n": null}`), &doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		expr  string
+		value any
+		ok    bool
+	}{
+		{"$", doc, true},
+		{"$.a.b", 1.0, true},
+		{"$['a']['b']", 1.0, true},
+		{"$.items[1].id", "y", true},
+		{"$['a b']", true, true},
+		{"$.n", nil, true},
+		{"$.missing", nil, false},
+		{"$.a.b.c", nil, false},
+		{"$.items[2]", nil, false},
+		{"$.items[-1]", nil, false},
+		{"$.items.id", nil, false},
+		{"$.a[0]", nil, false},
+		{"invalid", nil, false},
+	}
+
+	for _, tt := range tests {
+		value, ok := JSONPath(doc, tt.expr)
+		if ok != tt.ok || !reflect.DeepEqual(value, tt.value) {
+			t.Errorf("JSONPath(%q) = %v, %v, want %v, %v", tt.expr, value, ok, tt.value, tt.ok)
+		}
+	}
+}
@@ -0,0 +1,61 @@
+package matcher
+
+import (
+	"github.com/onrik/supermock/pkg/models"
+)
+
+// MethodAny matches requests with any method.
+const MethodAny = "ANY"
+
+// Match reports whether response answers request.
+// Request with test id matches only responses of that test.
+func Match(response models.Response, request models.Request) bool {
+	if request.TestID != "" && response.TestID != request.TestID {
+		return false
+	}
+
+	if Exhausted(response) {
+		return false
+	}
+
+	if response.Method != request.Method && response.Method != MethodAny {
+		return false
+	}
+
+	if response.PathRegex != "" {
+		if !MatchRegexp(response.PathRegex, request.Path) {
+			return false
+		}
+	} else if _, ok := MatchPath(response.Path, request.Path); !ok {
+		return false
+	}
+
+	return MatchQuery(response.Query, request.Query) &&
+		MatchHeaders(response.MatchHeaders, request.Headers) &&
+		MatchBody(response.MatchBody, request.Body)
+}
+
+// Exhausted reports whether all variants of sequence with not_implemented end were served.
+func Exhausted(response models.Response) bool {
+	return len(response.Sequence) > 0 &&
+		response.SequenceEnd == models.SequenceEndNotImplemented &&
+		response.Hits >= uint(len(response.Sequence))
+}
+
+// Select returns the first response matching request in order of responses.
+// Responses with exact path win over route templates and regular expressions.
+func Select(responses []models.Response, request models.Request) *models.Response {
+	for i := range responses {
+		if responses[i].PathRegex == "" && responses[i].Path == request.Path && Match(responses[i], request) {
+			return &responses[i]
+		}
+	}
+
+	for i := range responses {
+		if Match(responses[i], request) {
+			return &responses[i]
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,127 @@
+package matcher
+
+import (
+	"testing"
+
+	"github.com/onrik/supermock/pkg/models"
+)
+
+func TestSelect(t *testing.T) {
+	responses := []models.Response{
+		{UUID: "template", TestID: "t", Method: "GET", Path: "/users/{id}"},
+		{UUID: "exact", TestID: "t", Method: "GET", Path: "/users/me"},
+		{UUID: "any", TestID: "t", Method: MethodAny, Path: "/health"},
+		{UUID: "other test", TestID: "o", Method: "POST", Path: "/orders"},
+		{UUID: "regex", TestID: "t", Method: "POST", PathRegex: `^/orders/\d+$`},
+		{UUID: "exhausted", TestID: "t", Method: "GET", Path: "/seq", Sequence: []models.ResponseVariant{{Status: 200}}, SequenceEnd: models.SequenceEndNotImplemented, Hits: 1},
+	}
+
+	tests := []struct {
+		request models.Request
+		uuid    string
+	}{
+		{models.Request{TestID: "t", Method: "GET", Path: "/users/me"}, "exact"},
+		{models.Request{TestID: "t", Method: "GET", Path: "/users/1"}, "template"},
+		{models.Request{TestID: "t", Method: "POST", Path: "/users/1"}, ""},
+		{models.Request{TestID: "t", Method: "DELETE", Path: "/health"}, "any"},
+		{models.Request{Method: "POST", Path: "/orders"}, "other test"},
+		{models.Request{TestID: "t", Method: "POST", Path: "/orders"}, ""},
+		{models.Request{TestID: "t", Method: "POST", Path: "/orders/12"}, "regex"},
+		{models.Request{TestID: "t", Method: "GET", Path: "/seq"}, ""},
+	}
+
+	for _, tt := range tests {
+		response := Select(responses, tt.request)
+		uuid := ""
+		if response != nil {
+			uuid = response.UUID
+		}
+		if uuid != tt.uuid {
+			t.Errorf("Select(%s %s test %q) = %q, want %q", tt.request.Method, tt.request.Path, tt.request.TestID, uuid, tt.uuid)
+		}
+	}
+}
+
+func TestMatchPattern(t *testing.T) {
+	request := models.Request{
+		Method:  "POST",
+		Path:    "/users/1",
+		Query:   "a=1",
+		Headers: models.Headers{"X-Token": {"abc"}},
+		Body:    `{"name": "john"}`,
+	}
+
+	tests := []struct {
+		name    string
+		pattern models.RequestPattern
+		ok      bool
+	}{
+		{"empty", models.RequestPattern{}, true},
+		{"method", models.RequestPattern{Method: "POST"}, true},
+		{"any method", models.RequestPattern{Method: MethodAny}, true},
+		{"other method", models.RequestPattern{Method: "GET"}, false},
+		{"template path", models.RequestPattern{Path: "/users/{id}"}, true},
+		{"other path", models.RequestPattern{Path: "/users"}, false},
+		{"regex path", models.RequestPattern{PathRegex: `^/users/\d$`}, true},
+		{"query", models.RequestPattern{Query: &models.QueryMatcher{Params: map[string]models.ValueMatcher{"a": {Equal: "2"}}}}, false},
+		{"headers", models.RequestPattern{MatchHeaders: map[string]models.ValueMatcher{"x-token": {Equal: "abc"}}}, true},
+		{"body", models.RequestPattern{MatchBody: &models.BodyMatcher{JSONPath: map[string]any{"$.name": "john"}}}, true},
+	}
+
+	for _, tt := range tests {
+		if ok := MatchPattern(tt.pattern, request); ok != tt.ok {
+			t.Errorf("%s: MatchPattern = %v, want %v", tt.name, ok, tt.ok)
+		}
+	}
+}
+
+func TestMatchOrder(t *testing.T) {
+	requests := []models.Request{
+		{Method: "POST", Path: "/auth"},
+		{Method: "GET", Path: "/other"},
+		{Method: "GET", Path: "/profile"},
+		{Method: "POST", Path: "/audit"},
+	}
+	auth := models.RequestPattern{Method: "POST", Path: "/auth"}
+	profile := models.RequestPattern{Method: "GET", Path: "/profile"}
+	audit := models.RequestPattern{Method: "POST", Path: "/audit"}
+
+	tests := []struct {
+		name     string
+		patterns []models.RequestPattern
+		matched  int
+	}{
+		{"in order", []models.RequestPattern{auth, profile, audit}, 3},
+		{"subsequence", []models.RequestPattern{auth, audit}, 2},
+		{"wrong order", []models.RequestPattern{profile, auth, audit}, 1},
+		{"repeated", []models.RequestPattern{auth, auth}, 1},
+	}
+
+	for _, tt := range tests {
+		if matched := MatchOrder(tt.patterns, requests); len(matched) != tt.matched {
+			t.Errorf("%s: MatchOrder matched %d, want %d", tt.name, len(matched), tt.matched)
+		}
+	}
+}
+
+func TestMatchTimes(t *testing.T) {
+	tests := []struct {
+		times models.Times
+		count int
+		ok    bool
+	}{
+		{models.Times{Mode: models.TimesExactly, Count: 2}, 2, true},
+		{models.Times{Mode: models.TimesExactly, Count: 2}, 3, false},
+		{models.Times{Mode: models.TimesAtLeast, Count: 2}, 3, true},
+		{models.Times{Mode: models.TimesAtLeast, Count: 2}, 1, false},
+		{models.Times{Mode: models.TimesNever}, 0, true},
+		{models.Times{Mode: models.TimesNever}, 1, false},
+		{models.Times{Mode: "unknown"}, 0, false},
+	}
+
+	for _, tt := range tests {
+		if ok := MatchTimes(tt.times, tt.count); ok != tt.ok {
+			t.Errorf("MatchTimes(%+v, %d) = %v, want %v", tt.times, tt.count, ok, tt.ok)
+		}
+	}
+}
@@ -0,0 +1,102 @@
+package matcher
+
+import (
+	"strings"
+
+	"github.com/onrik/supermock/pkg/models"
+)
+
+// MatchPath reports whether path matches pattern.
+// Besides exact paths, pattern may be a route template with segments:
+//
+//	{name} - any single segment, captured as path parameter "name"
+//	*      - any single segment
+//	**     - any number of segments, including none
+func MatchPath(pattern, path string) (map[string]string, bool) {
+	params := map[string]string{}
+	if pattern == path {
+		return params, true
+	}
+
+	if !IsPathTemplate(pattern) {
+		return nil, false
+	}
+
+	ok := matchSegments(splitPath(pattern), splitPath(path), params)
+	if !ok {
+		return nil, false
+	}
+
+	return params, true
+}
+
+// IsPathTemplate reports whether pattern contains template segments.
+func IsPathTemplate(pattern string) bool {
+	for _, segment := range splitPath(pattern) {
+		if segment == "*" || segment == "**" || isParam(segment) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func matchSegments(pattern, path []string, params map[string]string) bool {
+	for i, segment := range pattern {
+		if segment == "**" {
+			rest := pattern[i+1:]
+			for j := i; j <= len(path); j++ {
+				if matchSegments(rest, path[j:], params) {
+					return true
+				}
+			}
+			return false
+		}
+
+		if i >= len(path) {
+			return false
+		}
+
+		switch {
+		case segment == "*":
+		case isParam(segment):
+			params[segment[1:len(segment)-1]] = path[i]
+		case segment != path[i]:
+			return false
+		}
+	}
+
+	return len(pattern) == len(path)
+}
+
+func isParam(segment string) bool {
+	return len(segment) > 2 && strings.HasPrefix(segment, "{") && strings.HasSuffix(segment, "}")
+}
+
+func splitPath(path string) []string {
+	return strings.Split(strings.Trim(path, "/"), "/")
+}
+
+// PathParams returns params captured from path by response route template
+// or named groups of response path regex.
+func PathParams(response models.Response, path string) map[string]string {
+	if response.PathRegex == "" {
+		params, _ := MatchPath(response.Path, path)
+		return params
+	}
+
+	params := map[string]string{}
+	re, err := compile(response.PathRegex)
+	if err != nil {
+		return params
+	}
+
+	match := re.FindStringSubmatch(path)
+	for i, name := range re.SubexpNames() {
+		if name != "" && i < len(match) {
+			params[name] = match[i]
+		}
+	}
+
+	return params
+}
@@ -0,0 +1,85 @@
+package matcher
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/onrik/supermock/pkg/models"
+)
+
+func TestMatchPath(t *testing.T) {
+	tests := []struct {
+		pattern string
+		path    string
+		ok      bool
+		params  map[string]string
+	}{
+		{"/users", "/users", true, map[string]string{}},
+		{"/users", "/users/", false, nil},
+		{"/users", "/accounts", false, nil},
+		{"/users/{id}", "/users/42", true, map[string]string{"id": "42"}},
+		{"/users/{id}", "/users/42/", true, map[string]string{"id": "42"}},
+		{"/users/{id}", "/users", false, nil},
+		{"/users/{id}", "/users/42/orders", false, nil},
+		{"/users/{id}/orders/{order}", "/users/1/orders/2", true, map[string]string{"id": "1", "order": "2"}},
+		{"/users/*", "/users/42", true, map[string]string{}},
+		{"/users/*", "/users/42/orders", false, nil},
+		{"/files/**", "/files", true, map[string]string{}},
+		{"/files/**", "/files/a/b/c", true, map[string]string{}},
+		{"/files/**/raw", "/files/raw", true, map[string]string{}},
+		{"/files/**/raw", "/files/a/b/raw", true, map[string]string{}},
+		{"/files/**/raw", "/files/a/b/raw/x", false, nil},
+		{"/files/**/{name}/raw", "/files/a/b/raw", true, map[string]string{"name": "b"}},
+		{"/**", "/anything/at/all", true, map[string]string{}},
+		{"/{}", "/x", false, nil},
+	}
+
+	for _, tt := range tests {
+		params, ok := MatchPath(tt.pattern, tt.path)
+		if ok != tt.ok {
+			t.Errorf("MatchPath(%q, %q) = %v, want %v", tt.pattern, tt.path, ok, tt.ok)
+			continue
+		}
+		if ok && !reflect.DeepEqual(params, tt.params) {
+			t.Errorf("MatchPath(%q, %q) params = %v, want %v", tt.pattern, tt.path, params, tt.params)
+		}
+	}
+}
+
+func TestIsPathTemplate(t *testing.T) {
+	tests := map[string]bool{
+		"/users":        false,
+		"/users/{id}":   true,
+		"/users/*":      true,
+		"/files/**":     true,
+		"/users/{}":     false,
+		"/users/x*":     false,
+		"/users/{id}/x": true,
+	}
+
+	for pattern, want := range tests {
+		if got := IsPathTemplate(pattern); got != want {
+			t.Errorf("IsPathTemplate(%q) = %v, want %v", pattern, got, want)
+		}
+	}
+}
+
+func TestPathParams(t *testing.T) {
+	tests := []struct {
+		response models.Response
+		path     string
+		params   map[string]string
+	}{
+		{models.Response{Path: "/users/{id}"}, "/users/7", map[string]string{"id": "7"}},
+		{models.Response{PathRegex: `^/users/(?P<id>\d+)$`}, "/users/7", map[string]string{"id": "7"}},
+		{models.Response{PathRegex: `^/users/(\d+)$`}, "/users/7", map[string]string{}},
+		{models.Response{PathRegex: `(`}, "/users/7", map[string]string{}},
+	}
+
+	for _, tt := range tests {
+		params := PathParams(tt.response, tt.path)
+		if !reflect.DeepEqual(params, tt.params) {
+			t.Errorf("PathParams(%+v, %q) = %v, want %v", tt.response, tt.path, params, tt.params)
+		}
+	}
+}
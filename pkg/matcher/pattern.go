@@ -0,0 +1,57 @@
+package matcher
+
+import (
+	"github.com/onrik/supermock/pkg/models"
+)
+
+// MatchPattern reports whether captured request matches pattern.
+func MatchPattern(pattern models.RequestPattern, request models.Request) bool {
+	if pattern.Method != "" && pattern.Method != MethodAny && pattern.Method != request.Method {
+		return false
+	}
+
+	if pattern.PathRegex != "" {
+		if !MatchRegexp(pattern.PathRegex, request.Path) {
+			return false
+		}
+	} else if pattern.Path != "" {
+		if _, ok := MatchPath(pattern.Path, request.Path); !ok {
+			return false
+		}
+	}
+
+	return MatchQuery(pattern.Query, request.Query) &&
+		MatchHeaders(pattern.MatchHeaders, request.Headers) &&
+		MatchBody(pattern.MatchBody, request.Body)
+}
+
+// MatchOrder returns requests matched by patterns one after another.
+// Requests must be ordered, not matching requests between them are skipped.
+// All patterns occurred in order if result has the same length as patterns.
+func MatchOrder(patterns []models.RequestPattern, requests []models.Request) []models.Request {
+	matched := []models.Request{}
+	for _, request := range requests {
+		if len(matched) == len(patterns) {
+			break
+		}
+		if MatchPattern(patterns[len(matched)], request) {
+			matched = append(matched, request)
+		}
+	}
+
+	return matched
+}
+
+// MatchTimes reports whether count of requests satisfies times.
+func MatchTimes(times models.Times, count int) bool {
+	switch times.Mode {
+	case models.TimesExactly:
+		return count == int(times.Count)
+	case models.TimesAtLeast:
+		return count >= int(times.Count)
+	case models.TimesNever:
+		return count == 0
+	}
+
+	return false
+}
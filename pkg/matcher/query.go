@@ -0,0 +1,36 @@
+package matcher
+
+import (
+	"net/url"
+
+	"github.com/onrik/supermock/pkg/models"
+)
+
+// MatchQuery reports whether raw query string satisfies m.
+// Nil matcher matches any query.
+func MatchQuery(m *models.QueryMatcher, rawQuery string) bool {
+	if m == nil {
+		return true
+	}
+
+	// Malformed pairs are skipped, the rest is still matched
+	values, _ := url.ParseQuery(rawQuery)
+
+	for name, param := range m.Params {
+		v, ok := values[name]
+		if !MatchValue(param, v, ok) {
+			return false
+		}
+	}
+
+	if m.Exact {
+		for name := range values {
+			param, ok := m.Params[name]
+			if !ok || param.Absent {
+				return false
+			}
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,92 @@
+package matcher
+
+import (
+	"testing"
+
+	"github.com/onrik/supermock/pkg/models"
+)
+
+func TestMatchValue(t *testing.T) {
+	tests := []struct {
+		name    string
+		m       models.ValueMatcher
+		values  []string
+		present bool
+		ok      bool
+	}{
+		{"present", models.ValueMatcher{}, []string{""}, true, true},
+		{"missing", models.ValueMatcher{}, nil, false, false},
+		{"absent", models.ValueMatcher{Absent: true}, nil, false, true},
+		{"absent but sent", models.ValueMatcher{Absent: true}, []string{"1"}, true, false},
+		{"equal", models.ValueMatcher{Equal: "1"}, []string{"1"}, true, true},
+		{"not equal", models.ValueMatcher{Equal: "1"}, []string{"2"}, true, false},
+		{"equal any value", models.ValueMatcher{Equal: "2"}, []string{"1", "2"}, true, true},
+		{"regex", models.ValueMatcher{Regex: `^\d+$`}, []string{"42"}, true, true},
+		{"regex mismatch", models.ValueMatcher{Regex: `^\d+$`}, []string{"x42"}, true, false},
+		{"equal and regex", models.ValueMatcher{Equal: "42", Regex: `^4`}, []string{"42"}, true, true},
+		{"equal and regex on different values", models.ValueMatcher{Equal: "42", Regex: `^5`}, []string{"42", "5"}, true, false},
+		{"invalid regex", models.ValueMatcher{Regex: `(`}, []string{"("}, true, false},
+	}
+
+	for _, tt := range tests {
+		if ok := MatchValue(tt.m, tt.values, tt.present); ok != tt.ok {
+			t.Errorf("%s: MatchValue = %v, want %v", tt.name, ok, tt.ok)
+		}
+	}
+}
+
+func TestMatchQuery(t *testing.T) {
+	tests := []struct {
+		name  string
+		m     *models.QueryMatcher
+		query string
+		ok    bool
+	}{
+		{"nil matcher", nil, "a=1", true},
+		{"equal", &models.QueryMatcher{Params: map[string]models.ValueMatcher{"a": {Equal: "1"}}}, "a=1&b=2", true},
+		{"not equal", &models.QueryMatcher{Params: map[string]models.ValueMatcher{"a": {Equal: "1"}}}, "a=2", false},
+		{"missing", &models.QueryMatcher{Params: map[string]models.ValueMatcher{"a": {}}}, "b=2", false},
+		{"repeated", &models.QueryMatcher{Params: map[string]models.ValueMatcher{"a": {Equal: "2"}}}, "a=1&a=2", true},
+		{"escaped", &models.QueryMatcher{Params: map[string]models.ValueMatcher{"q": {Equal: "a b"}}}, "q=a+b", true},
+		{"absent", &models.QueryMatcher{Params: map[string]models.ValueMatcher{"debug": {Absent: true}}}, "a=1", true},
+		{"exact", &models.QueryMatcher{Params: map[string]models.ValueMatcher{"a": {}}, Exact: true}, "a=1", true},
+		{"exact extra param", &models.QueryMatcher{Params: map[string]models.ValueMatcher{"a": {}}, Exact: true}, "a=1&b=2", false},
+		{"exact absent param sent", &models.QueryMatcher{Params: map[string]models.ValueMatcher{"a": {}, "b": {Absent: true}}, Exact: true}, "a=1&b=2", false},
+		{"exact empty", &models.QueryMatcher{Exact: true}, "", true},
+		{"malformed pair skipped", &models.QueryMatcher{Params: map[string]models.ValueMatcher{"a": {Equal: "1"}}}, "a=1&b=%zz", true},
+	}
+
+	for _, tt := range tests {
+		if ok := MatchQuery(tt.m, tt.query); ok != tt.ok {
+			t.Errorf("%s: MatchQuery(%q) = %v, want %v", tt.name, tt.query, ok, tt.ok)
+		}
+	}
+}
+
+func TestMatchHeaders(t *testing.T) {
+	headers := models.Headers{
+		"Authorization": {"Bearer token"},
+		"Accept":        {"text/html", "application/json"},
+	}
+
+	tests := []struct {
+		name     string
+		matchers map[string]models.ValueMatcher
+		ok       bool
+	}{
+		{"nil matchers", nil, true},
+		{"equal", map[string]models.ValueMatcher{"Authorization": {Equal: "Bearer token"}}, true},
+		{"case insensitive name", map[string]models.ValueMatcher{"authorization": {Equal: "Bearer token"}}, true},
+		{"second value", map[string]models.ValueMatcher{"Accept": {Equal: "application/json"}}, true},
+		{"regex", map[string]models.ValueMatcher{"Authorization": {Regex: "^Bearer "}}, true},
+		{"not equal", map[string]models.ValueMatcher{"Authorization": {Equal: "Bearer other"}}, false},
+		{"missing", map[string]models.ValueMatcher{"X-Api-Key": {}}, false},
+		{"absent", map[string]models.ValueMatcher{"X-Api-Key": {Absent: true}}, true},
+	}
+
+	for _, tt := range tests {
+		if ok := MatchHeaders(tt.matchers, headers); ok != tt.ok {
+			t.Errorf("%s: MatchHeaders = %v, want %v", tt.name, ok, tt.ok)
+		}
+	}
+}
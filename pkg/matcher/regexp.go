@@ -0,0 +1,35 @@
+package matcher
+
+import (
+	"regexp"
+	"sync"
+)
+
+var regexps sync.Map
+
+// compile returns cached compiled expression.
+func compile(expr string) (*regexp.Regexp, error) {
+	if re, ok := regexps.Load(expr); ok {
+		return re.(*regexp.Regexp), nil
+	}
+
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return nil, err
+	}
+
+	regexps.Store(expr, re)
+
+	return re, nil
+}
+
+// MatchRegexp reports whether s contains any match of expr.
+// Invalid expressions never match.
+func MatchRegexp(expr, s string) bool {
+	re, err := compile(expr)
+	if err != nil {
+		return false
+	}
+
+	return re.MatchString(s)
+}
@@ -0,0 +1,33 @@
+package matcher
+
+import (
+	"github.com/onrik/supermock/pkg/models"
+)
+
+// MatchValue reports whether any of values satisfies m.
+// present tells whether the value was sent at all.
+func MatchValue(m models.ValueMatcher, values []string, present bool) bool {
+	if m.Absent {
+		return !present
+	}
+
+	if !present {
+		return false
+	}
+
+	if m.Equal == "" && m.Regex == "" {
+		return true
+	}
+
+	for _, v := range values {
+		if m.Equal != "" && v != m.Equal {
+			continue
+		}
+		if m.Regex != "" && !MatchRegexp(m.Regex, v) {
+			continue
+		}
+		return true
+	}
+
+	return false
+}
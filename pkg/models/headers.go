@@ -0,0 +1,38 @@
+package models
+
+import (
+	"encoding/json"
+)
+
+// Headers can contain several values of the same header.
+// Single string values are decoded too for backward compatibility.
+type Headers map[string][]string
+
+func (h *Headers) UnmarshalJSON(data []byte) error {
+	raw := map[string]json.RawMessage{}
+	err := json.Unmarshal(data, &raw)
+	if err != nil {
+		return err
+	}
+
+	headers := make(Headers, len(raw))
+	for k, v := range raw {
+		values := []string{}
+		err = json.Unmarshal(v, &values)
+		if err == nil {
+			headers[k] = values
+			continue
+		}
+
+		value := ""
+		err = json.Unmarshal(v, &value)
+		if err != nil {
+			return err
+		}
+		headers[k] = []string{value}
+	}
+
+	*h = headers
+
+	return nil
+}
@@ -1,26 +1,228 @@
 package models
 
+import (
+	"encoding/json"
+	"time"
+)
+
+// TimeFormat is format of created_at with microseconds.
+// It has fixed width, so timestamps sort as strings.
+const TimeFormat = "2006-01-02T15:04:05.000000Z07:00"
+
 type Request struct {
-	TestID    string            `json:"test_id" openapi:"format=uuid"`
-	Method    string            `json:"method"`
-	Query     string            `json:"query"`
-	Path      string            `json:"path"`
-	Headers   map[string]string `json:"headers"`
-	Body      string            `json:"body"`
-	CreatedAt string            `json:"created_at" openapi:"format=date-time"`
+	// Sequence grows monotonically with every captured request
+	Sequence   int64   `json:"sequence"`
+	TestID     string  `json:"test_id" openapi:"format=uuid"`
+	Method     string  `json:"method"`
+	Query      string  `json:"query"`
+	Path       string  `json:"path"`
+	Headers    Headers `json:"headers"`
+	Body       string  `json:"body"`
+	BodyBase64 string  `json:"body_base64,omitempty"`
+	// CreatedAt is time the request was received
+	CreatedAt string `json:"created_at" openapi:"format=date-time"`
+	// Response that answered the request and what was actually sent
+	ResponseUUID       string  `json:"response_uuid,omitempty"`
+	ResponseStatus     uint16  `json:"response_status,omitempty"`
+	ResponseHeaders    Headers `json:"response_headers,omitempty"`
+	ResponseBody       string  `json:"response_body,omitempty"`
+	ResponseBodyBase64 string  `json:"response_body_base64,omitempty"`
+	ResponseFault      string  `json:"response_fault,omitempty"`
+	LatencyMs          int64   `json:"latency_ms"`
+	// Unmatched is set for request without matching response
+	Unmatched bool `json:"unmatched,omitempty"`
 }
 
 type Response struct {
-	ID           int64             `json:"-"`
-	UUID         string            `json:"uuid" validate:"required" openapi:"format=uuid"`
-	TestID       string            `json:"test_id" validate:"required" openapi:"format=uuid"`
-	Method       string            `json:"method" validate:"required"`
-	Path         string            `json:"path" validate:"required"`
-	Status       uint16            `json:"status" validate:"required"`
-	Headers      map[string]string `json:"headers"`
-	Body         string            `json:"body"`
-	IsPermanent  bool              `json:"is_permanent"`
-	DisableCatch bool              `json:"disable_catch"`
+	ID           int64                   `json:"-"`
+	UUID         string                  `json:"uuid" validate:"required" openapi:"format=uuid"`
+	TestID       string                  `json:"test_id" validate:"required" openapi:"format=uuid"`
+	Method       string                  `json:"method" validate:"required"`
+	Path         string                  `json:"path" validate:"required_without=PathRegex"`
+	PathRegex    string                  `json:"path_regex" validate:"omitempty,regexp"`
+	Query        *QueryMatcher           `json:"query,omitempty"`
+	MatchHeaders map[string]ValueMatcher `json:"match_headers,omitempty" validate:"dive"`
+	MatchBody    *BodyMatcher            `json:"match_body,omitempty"`
+	Status       uint16                  `json:"status" validate:"required_without_all=Sequence Fault Alternatives"`
+	Headers      Headers                 `json:"headers"`
+	Body         string                  `json:"body"`
+	BodyBase64   string                  `json:"body_base64,omitempty" validate:"omitempty,base64"`
+	BodyJSON     json.RawMessage         `json:"body_json,omitempty" validate:"excluded_with=Body BodyBase64"`
+	IsPermanent  bool                    `json:"is_permanent"`
+	DisableCatch bool                    `json:"disable_catch"`
+	// Times is number of uses of not permanent response, 0 means once
+	Times uint `json:"times" validate:"excluded_with=IsPermanent"`
+	// Hits is number of times response was served
+	Hits uint `json:"hits"`
+	// Remaining is number of uses left for not permanent response
+	Remaining uint `json:"remaining,omitempty"`
+	// Sequence is served in turn instead of status, headers and body.
+	// Not permanent response with sequence is served len(Sequence) times by default.
+	Sequence    []ResponseVariant `json:"sequence,omitempty" validate:"dive"`
+	SequenceEnd string            `json:"sequence_end,omitempty" validate:"omitempty,oneof=repeat_last cycle not_implemented"`
+	// Template enables rendering of body and header values as text/template with request data
+	Template bool `json:"template"`
+	// DelayMs is delay before response. Uniform distribution picks delay from [DelayMs, DelayMaxMs],
+	// normal one uses DelayMs as mean with DelayStddevMs deviation.
+	DelayMs           uint   `json:"delay_ms"`
+	DelayDistribution string `json:"delay_distribution,omitempty" validate:"omitempty,oneof=fixed uniform normal"`
+	DelayMaxMs        uint   `json:"delay_max_ms,omitempty" validate:"omitempty,gtefield=DelayMs"`
+	DelayStddevMs     uint   `json:"delay_stddev_ms,omitempty"`
+	// Fault breaks connection instead of normal response
+	Fault string `json:"fault,omitempty" validate:"omitempty,oneof=empty_response connection_reset headers_then_hang truncated_body garbage"`
+	// Alternatives of permanent response are chosen randomly by weight instead of status, headers and body
+	Alternatives []Alternative `json:"alternatives,omitempty" validate:"excluded_without=IsPermanent,excluded_with=Sequence,dive"`
+	// TTLSeconds is lifetime of response, expired response doesn't match and is deleted
+	TTLSeconds uint `json:"ttl_seconds,omitempty"`
+	// ExpiresAt is set from TTLSeconds on save
+	ExpiresAt string `json:"expires_at,omitempty" openapi:"format=date-time"`
+}
+
+type Alternative struct {
+	Weight uint `json:"weight" validate:"required"`
+	ResponseVariant
+}
+
+// Faults
+const (
+	// FaultEmptyResponse closes connection without response
+	FaultEmptyResponse = "empty_response"
+	// FaultConnectionReset resets TCP connection
+	FaultConnectionReset = "connection_reset"
+	// FaultHeadersThenHang sends status and headers and waits until client closes connection
+	FaultHeadersThenHang = "headers_then_hang"
+	// FaultTruncatedBody sends body shorter than Content-Length
+	FaultTruncatedBody = "truncated_body"
+	// FaultGarbage sends random bytes instead of HTTP response
+	FaultGarbage = "garbage"
+)
+
+// Delay distributions
+const (
+	DelayFixed   = "fixed"
+	DelayUniform = "uniform"
+	DelayNormal  = "normal"
+)
+
+// Behaviour of permanent response after the last variant of sequence
+const (
+	SequenceEndRepeatLast     = "repeat_last"
+	SequenceEndCycle          = "cycle"
+	SequenceEndNotImplemented = "not_implemented"
+)
+
+type ResponseVariant struct {
+	Status     uint16  `json:"status" validate:"required_without=Fault"`
+	Headers    Headers `json:"headers"`
+	Body       string  `json:"body"`
+	BodyBase64 string  `json:"body_base64,omitempty" validate:"omitempty,base64"`
+	Fault      string  `json:"fault,omitempty" validate:"omitempty,oneof=empty_response connection_reset headers_then_hang truncated_body garbage"`
+	DelayMs    uint    `json:"delay_ms,omitempty"`
+}
+
+// ValueMatcher matches query param or header value.
+// Empty matcher requires value to be present.
+type ValueMatcher struct {
+	Equal  string `json:"equal,omitempty"`
+	Regex  string `json:"regex,omitempty" validate:"omitempty,regexp"`
+	Absent bool   `json:"absent,omitempty"`
+}
+
+type QueryMatcher struct {
+	Params map[string]ValueMatcher `json:"params" validate:"dive"`
+	// Exact disallows params not listed in Params
+	Exact bool `json:"exact"`
+}
+
+// BodyMatcher matches JSON request body.
+type BodyMatcher struct {
+	// EqualJSON requires body to be equal JSON ignoring keys order and whitespaces
+	EqualJSON any `json:"equal_json,omitempty"`
+	// ContainsJSON requires body to contain all fields of value
+	ContainsJSON any `json:"contains_json,omitempty"`
+	// JSONPath maps expressions like $.items[0].id to expected values
+	JSONPath map[string]any `json:"json_path,omitempty" validate:"dive,keys,jsonpath,endkeys"`
+}
+
+// RequestFilter selects captured requests.
+// Empty fields don't filter.
+type RequestFilter struct {
+	TestID string `param:"test_id" query:"test_id"`
+	// Unmatched selects requests without matching response instead of answered ones
+	Unmatched  bool
+	Method     string `query:"method"`
+	PathPrefix string `query:"path_prefix"`
+	// Header and Body are substrings of headers JSON and body
+	Header string `query:"header"`
+	Body   string `query:"body"`
+	// From and To limit time requests were received, To is exclusive
+	From time.Time `query:"from"`
+	To   time.Time `query:"to"`
+	// Cursor is sequence of the last request of previous page
+	Cursor int64  `query:"cursor" validate:"gte=0"`
+	Limit  int    `query:"limit" validate:"gte=0"`
+	Offset int    `query:"offset" validate:"gte=0"`
+	Sort   string `query:"sort" validate:"omitempty,oneof=asc desc"`
+}
+
+// Sort orders
+const (
+	SortAsc  = "asc"
+	SortDesc = "desc"
+)
+
+// RequestPattern matches captured requests.
+// Empty fields match any request.
+type RequestPattern struct {
+	Method       string                  `json:"method,omitempty"`
+	Path         string                  `json:"path,omitempty"`
+	PathRegex    string                  `json:"path_regex,omitempty" validate:"omitempty,regexp"`
+	Query        *QueryMatcher           `json:"query,omitempty"`
+	MatchHeaders map[string]ValueMatcher `json:"match_headers,omitempty" validate:"dive"`
+	MatchBody    *BodyMatcher            `json:"match_body,omitempty"`
+}
+
+// Times is expected number of requests.
+type Times struct {
+	Mode  string `json:"mode" validate:"required,oneof=exactly at_least never"`
+	Count uint   `json:"count"`
+}
+
+// Times modes
+const (
+	TimesExactly = "exactly"
+	TimesAtLeast = "at_least"
+	TimesNever   = "never"
+)
+
+// Verification expects number of test requests matching pattern.
+type Verification struct {
+	TestID  string         `json:"test_id" validate:"required"`
+	Pattern RequestPattern `json:"pattern"`
+	Times   Times          `json:"times"`
+}
+
+type VerificationResult struct {
+	Passed bool `json:"passed"`
+	// Count is number of requests matching pattern
+	Count      int       `json:"count"`
+	Matched    []Request `json:"matched"`
+	Mismatched []Request `json:"mismatched"`
+}
+
+// OrderVerification expects test requests matching patterns in the same order.
+// Other requests may occur between them.
+type OrderVerification struct {
+	TestID   string           `json:"test_id" validate:"required"`
+	Patterns []RequestPattern `json:"patterns" validate:"required,min=1,dive"`
+}
+
+type OrderVerificationResult struct {
+	Passed bool `json:"passed"`
+	// Matched are requests matched by patterns, the first not matched pattern has index len(Matched)
+	Matched []Request `json:"matched"`
+	// Requests are all test requests in order they were received
+	Requests []Request `json:"requests"`
 }
 
 type Email struct {